@@ -3,6 +3,7 @@ module github.com/orlangure/gocovsh
 go 1.19
 
 require (
+	github.com/catppuccin/go v0.2.0
 	github.com/charmbracelet/bubbles v0.10.2
 	github.com/charmbracelet/bubbletea v0.19.3
 	github.com/charmbracelet/lipgloss v0.4.0
@@ -10,9 +11,9 @@ require (
 	github.com/muesli/termenv v0.9.0
 	github.com/sebdah/goldie/v2 v2.5.3
 	github.com/stretchr/testify v1.7.0
-	github.com/waigani/diffparser v0.0.0-20190828052634-7391f219313d
-	golang.org/x/tools v0.1.8
-	github.com/catppuccin/go v0.2.0
+	golang.org/x/mod v0.10.0
+	golang.org/x/tools v0.1.12
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -29,8 +30,7 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/sahilm/fuzzy v0.1.0 // indirect
 	github.com/sergi/go-diff v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
+	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
 	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
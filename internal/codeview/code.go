@@ -64,6 +64,7 @@ func New(width, height int) Model {
 	return Model{
 		viewport: viewport.New(width, height),
 		help:     help.New(),
+		keyMap:   DefaultKeyMap,
 		showHelp: true,
 	}
 }
@@ -72,6 +73,7 @@ func New(width, height int) Model {
 type Model struct {
 	viewport      viewport.Model
 	help          help.Model
+	keyMap        KeyMap
 	width         int
 	height        int
 	title         string
@@ -85,12 +87,12 @@ type Model struct {
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	// TODO: support number-based navigation <29-01-22, yury> //
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		if key.Matches(msg, DefaultKeyMap.Home) {
+		if key.Matches(msg, m.keyMap.Home) {
 			_ = m.viewport.GotoTop()
 			return m, nil
 		}
 
-		if key.Matches(msg, DefaultKeyMap.End) {
+		if key.Matches(msg, m.keyMap.End) {
 			_ = m.viewport.GotoBottom()
 			return m, nil
 		}
@@ -126,6 +128,15 @@ func (m *Model) SetContent(lines []string) {
 	m.viewport.SetYOffset(0)
 }
 
+// ReplaceContent sets the content of the codeview, keeping the current scroll
+// position when possible.
+func (m *Model) ReplaceContent(lines []string) {
+	offset := m.viewport.YOffset
+	m.lines = lines
+	m.redrawLines()
+	m.viewport.SetYOffset(offset)
+}
+
 // SetFilteredLines sets the lines that should be displayed, while all other
 // lines are hidden. If not set, everything is displayed.
 func (m *Model) SetFilteredLines(filteredLines []int) {
@@ -134,6 +145,33 @@ func (m *Model) SetFilteredLines(filteredLines []int) {
 	m.viewport.SetYOffset(0)
 }
 
+// ScrollToLine scrolls the codeview so that the line of the source code is at
+// the top. When the lines are filtered, the closest displayed line after it
+// is used.
+func (m *Model) ScrollToLine(line int) {
+	if len(m.filteredLines.actualLines) == 0 {
+		m.viewport.SetYOffset(line - 1)
+		return
+	}
+
+	row, lastPrintedLine := 0, 0
+
+	for _, thisLineNumber := range m.filteredLines.actualLines {
+		if thisLineNumber-lastPrintedLine > 1 {
+			row++
+		}
+
+		if thisLineNumber >= line {
+			break
+		}
+
+		row++
+		lastPrintedLine = thisLineNumber
+	}
+
+	m.viewport.SetYOffset(row)
+}
+
 func (m *Model) redrawLines() {
 	content := m.formatLines(m.lines)
 	m.viewport.SetContent(content)
@@ -167,23 +205,29 @@ func (m *Model) SetTitle(title string) {
 // ShortHelp implements  help.KeyMap interface.
 func (m *Model) ShortHelp() []key.Binding {
 	return []key.Binding{
-		DefaultKeyMap.Up,
-		DefaultKeyMap.Down,
-		DefaultKeyMap.Home,
-		DefaultKeyMap.End,
-		DefaultKeyMap.Back,
+		m.keyMap.Up,
+		m.keyMap.Down,
+		m.keyMap.Home,
+		m.keyMap.End,
+		m.keyMap.Back,
 	}
 }
 
 // FullHelp implements  help.KeyMap interface.
 func (m *Model) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{DefaultKeyMap.Up, DefaultKeyMap.Down, DefaultKeyMap.Home, DefaultKeyMap.End},
-		{DefaultKeyMap.HalfScreenDown, DefaultKeyMap.HalfScreenUp},
-		{DefaultKeyMap.Back, DefaultKeyMap.Quit},
+		{m.keyMap.Up, m.keyMap.Down, m.keyMap.Home, m.keyMap.End},
+		{m.keyMap.HalfScreenDown, m.keyMap.HalfScreenUp},
+		{m.keyMap.Back, m.keyMap.Quit},
 	}
 }
 
+// SetKeyMap replaces the key bindings shown in the help section and used for
+// navigation.
+func (m *Model) SetKeyMap(keyMap KeyMap) {
+	m.keyMap = keyMap
+}
+
 // SetShowHelp allows to hide or show the help section.
 func (m *Model) SetShowHelp(showHelp bool) {
 	m.showHelp = showHelp
@@ -336,6 +380,15 @@ func max(a, b int) int {
 	return b
 }
 
+// WithContext extends the provided line numbers with the lines around them,
+// the same way the filtered lines are displayed in the codeview. It returns
+// all the lines to display, and the lines that were added as context.
+func WithContext(lines []int) ([]int, map[int]bool) {
+	fl := contextifyFilteredLines(lines)
+
+	return fl.actualLines, fl.contextLines
+}
+
 func contextifyFilteredLines(input []int) filteredLines {
 	if len(input) == 0 {
 		return filteredLines{
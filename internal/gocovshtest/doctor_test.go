@@ -0,0 +1,42 @@
+package gocovshtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblems(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/doctor"))
+
+	dir := copyDir(t, "testdata/doctor")
+
+	// the source is modified after the coverage profile
+	stale := filepath.Join(dir, "stale.go")
+	fi, err := os.Stat(stale)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(stale, fi.ModTime(), fi.ModTime().Add(time.Hour)))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        dir,
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	mm, cmd := mt.sendWindowSizeMsg(70, 16)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	mm, cmd = mt.sendProfilesMsg(initMsg)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	g.Assert(t, "problems_badges", []byte(mm.View()))
+}
@@ -1,6 +1,7 @@
 package gocovshtest
 
 import (
+	"errors"
 	"runtime"
 	"testing"
 
@@ -17,6 +18,7 @@ func TestErrorFlows(t *testing.T) {
 	t.Run("missing coverage file", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "missing.cover",
 			codeRoot:        "testdata/general",
 		}
@@ -48,6 +50,7 @@ func TestErrorFlows(t *testing.T) {
 	t.Run("missing go.mod file", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "coverage.out",
 			codeRoot:        "testdata/no-go.mod",
 		}
@@ -71,6 +74,7 @@ func TestErrorFlows(t *testing.T) {
 	t.Run("invalid coverage file", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "invalid.profile",
 			codeRoot:        "testdata/errors",
 		}
@@ -90,6 +94,7 @@ func TestErrorFlows(t *testing.T) {
 	t.Run("no profiles", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "empty.profile",
 			codeRoot:        "testdata/errors",
 		}
@@ -106,9 +111,30 @@ func TestErrorFlows(t *testing.T) {
 		g.Assert(t, "error_flows_empty_coverage_file", []byte(mm.View()))
 	})
 
+	t.Run("conflicting modes", func(t *testing.T) {
+		mt := &modelTest{
+			T:                t,
+			inPlace:          true,
+			profileFilenames: []string{"profile.cover", "count.cover"},
+			codeRoot:         "testdata/general",
+		}
+		initCmd := mt.init()
+		initMsg := initCmd()
+
+		mm, cmd := mt.sendWindowSizeMsg(60, 20)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		mm, cmd = mt.sendErrorMsg(initMsg)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+		g.Assert(t, "error_flows_conflicting_modes", []byte(mm.View()))
+	})
+
 	t.Run("invalid source file name", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "coverage.profile",
 			codeRoot:        "testdata/errors",
 		}
@@ -143,6 +169,7 @@ func TestErrorFlows(t *testing.T) {
 	t.Run("invalid go.mod", func(t *testing.T) {
 		mt := &modelTest{
 			T:               t,
+			inPlace:         true,
 			profileFilename: "cover.profile",
 			codeRoot:        "testdata/errors/badmodule",
 		}
@@ -158,4 +185,25 @@ func TestErrorFlows(t *testing.T) {
 		require.Nil(t, cmd)
 		g.Assert(t, "error_flows_invalid_go.mod", []byte(mm.View()))
 	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		mt := &modelTest{
+			T:               t,
+			inPlace:         true,
+			profileFilename: "coverage.profile",
+			codeRoot:        "testdata/errors",
+			inputErr:        errors.New("line 3: invalid hunk header: \"@@ -1 +x @@\""),
+		}
+		initCmd := mt.init()
+		initMsg := initCmd()
+
+		mm, cmd := mt.sendWindowSizeMsg(60, 20)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		mm, cmd = mt.sendErrorMsg(initMsg)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+		g.Assert(t, "error_flows_invalid_input", []byte(mm.View()))
+	})
 }
@@ -0,0 +1,82 @@
+package gocovshtest
+
+import (
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncsView(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/funcs"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/funcs",
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	mm, cmd := mt.sendWindowSizeMsg(60, 16)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	mm, cmd = mt.sendProfilesMsg(initMsg)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	t.Run("functions of all files", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('F')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, _ = mt.m.Update(cmd())
+		g.Assert(t, "funcs_list", []byte(mm.View()))
+	})
+
+	t.Run("sort by coverage", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('s')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "funcs_sorted", []byte(mm.View()))
+	})
+
+	t.Run("filter functions", func(t *testing.T) {
+		mm := mt.filter("each")
+		g.Assert(t, "funcs_filtered", []byte(mm.View()))
+	})
+
+	t.Run("open function", func(t *testing.T) {
+		mm, cmd := mt.sendEnterKey()
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, cmd = mt.sendFileContentsMsg(cmd())
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "funcs_code", []byte(mm.View()))
+	})
+
+	t.Run("back to functions", func(t *testing.T) {
+		mm, cmd := mt.sendEscKey()
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "funcs_filtered", []byte(mm.View()))
+	})
+
+	t.Run("back to files", func(t *testing.T) {
+		_, cmd := mt.sendEscKey()
+		mt.runCmd(cmd)
+
+		mm, cmd := mt.sendEscKey()
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "funcs_files", []byte(mm.View()))
+	})
+}
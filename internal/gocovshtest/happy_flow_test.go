@@ -27,6 +27,18 @@ func TestHappyFlow(t *testing.T) {
 			},
 		)
 	})
+
+	t.Run("same lines in many files", func(t *testing.T) {
+		testHappyFlow(
+			t,
+			"filtered-many",
+			nil,
+			map[string][]int{
+				"covered.go": {4},
+				"partial_with_a_very_long_name_to_trigger_ellipsis_in_the_output.go": {4},
+			},
+		)
+	})
 }
 
 func testHappyFlow(t *testing.T, prefix string, requestedFiles []string, filteredLines map[string][]int) {
@@ -164,3 +176,38 @@ func testHappyFlow(t *testing.T, prefix string, requestedFiles []string, filtere
 		})
 	})
 }
+
+func TestNoChangedGoFiles(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/general/no-go-changes"))
+
+	tests := []struct {
+		name           string
+		requestedFiles []string
+	}{
+		{name: "other files", requestedFiles: []string{"README.md"}},
+		{name: "empty diff", requestedFiles: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mt := &modelTest{
+				T:               t,
+				profileFilename: "profile.cover",
+				codeRoot:        "testdata/general",
+				requestedFiles:  test.requestedFiles,
+				filteredLines:   map[string][]int{},
+			}
+
+			initCmd := mt.init()
+			initMsg := initCmd()
+
+			_, _ = mt.sendWindowSizeMsg(60, 20)
+
+			mm, cmd := mt.sendProfilesMsg(initMsg)
+			require.NotNil(t, mm)
+			require.Nil(t, cmd)
+
+			g.Assert(t, "no_go_changes", []byte(mm.View()))
+		})
+	}
+}
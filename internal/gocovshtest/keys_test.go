@@ -0,0 +1,65 @@
+package gocovshtest
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyBindings(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/config"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "cover.out",
+		codeRoot:        "testdata/config",
+		keyBindings: map[string][]string{
+			"open": {"o"},
+			"back": {"c", "esc"},
+			"quit": {"Q"},
+			"help": {"H"},
+		},
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	mm, cmd := mt.sendWindowSizeMsg(60, 14)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	mm, cmd = mt.sendProfilesMsg(initMsg)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	t.Run("full help shows the keys", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('H')
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "keys_list_help", []byte(mm.View()))
+	})
+
+	t.Run("open file", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('o')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, cmd = mt.sendFileContentsMsg(cmd())
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "keys_code_help", []byte(mm.View()))
+	})
+
+	t.Run("go back and quit", func(t *testing.T) {
+		_, cmd := mt.sendLetterKey('c')
+		require.Nil(t, cmd)
+
+		_, cmd = mt.sendLetterKey('Q')
+		require.NotNil(t, cmd)
+		require.Equal(t, tea.Quit(), cmd())
+	})
+}
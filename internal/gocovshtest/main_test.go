@@ -1,8 +1,12 @@
 package gocovshtest
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -12,31 +16,101 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// fixtures is a copy of the test data used by the models. All the files have
+// the same modification time, so that the checkout order does not make the
+// sources look modified after the coverage profiles were written.
+var fixtures string
+
 func TestMain(m *testing.M) {
 	lipgloss.SetColorProfile(termenv.TrueColor)
-	os.Setenv("GOCOVSH_THEME", "default")
-	styles.SetTheme()
 
-	os.Exit(m.Run())
+	if err := styles.UseTheme("default"); err != nil {
+		panic(err)
+	}
+
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "gocovshtest")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := copyFiles("testdata", dir); err != nil {
+		panic(err)
+	}
+
+	fixtures = dir
+
+	return m.Run()
+}
+
+// fixture returns the location of the test data directory in the copy.
+func fixture(dir string) string {
+	if !strings.HasPrefix(dir, "testdata/") {
+		return dir
+	}
+
+	return filepath.Join(fixtures, strings.TrimPrefix(dir, "testdata/"))
+}
+
+func resetModTimes(dir string) error {
+	modTime := time.Date(2022, 1, 29, 0, 0, 0, 0, time.UTC)
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		return os.Chtimes(path, modTime, modTime)
+	})
 }
 
 type modelTest struct {
 	*testing.T
 
-	profileFilename string
-	codeRoot        string
-	requestedFiles  []string
-	filteredLines   map[string][]int
+	profileFilename  string
+	profileFilenames []string
+	codeRoot         string
+	requestedFiles   []string
+	includePatterns  []string
+	excludePatterns  []string
+	filteredLines    map[string][]int
+	watchInterval    time.Duration
+	inputErr         error
+	keyBindings      map[string][]string
+
+	// inPlace uses the test data instead of its copy, for the tests that
+	// show the paths of the files
+	inPlace bool
 
 	m *model.Model
 }
 
 func (t *modelTest) init() tea.Cmd {
+	profileOption := model.WithProfileFilename(t.profileFilename)
+	if len(t.profileFilenames) > 0 {
+		profileOption = model.WithProfileFilenames(t.profileFilenames...)
+	}
+
+	codeRoot := t.codeRoot
+	if !t.inPlace {
+		codeRoot = fixture(codeRoot)
+	}
+
 	t.m = model.New(
-		model.WithProfileFilename(t.profileFilename),
-		model.WithCodeRoot(t.codeRoot),
+		profileOption,
+		model.WithCodeRoot(codeRoot),
 		model.WithRequestedFiles(t.requestedFiles),
+		model.WithIncludePatterns(t.includePatterns...),
+		model.WithExcludePatterns(t.excludePatterns...),
 		model.WithFilteredLines(t.filteredLines),
+		model.WithWatch(t.watchInterval),
+		model.WithInputError(t.inputErr),
+		model.WithKeyBindings(t.keyBindings),
 	)
 
 	initCmd := t.m.Init()
@@ -78,3 +152,45 @@ func (t *modelTest) sendLetterKey(letter rune) (tea.Model, tea.Cmd) {
 		Runes: []rune{letter},
 	}))
 }
+
+// copyDir copies the test data into a temporary directory that can be
+// changed by the test.
+func copyDir(t *testing.T, src string) string {
+	t.Helper()
+
+	dst := t.TempDir()
+	require.NoError(t, copyFiles(src, dst))
+
+	return dst
+}
+
+// copyFiles copies the directory, and resets the modification times of the
+// copied files.
+func copyFiles(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0o700)
+		}
+
+		bs, err := os.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(dst, rel), bs, 0o600)
+	})
+	if err != nil {
+		return err
+	}
+
+	return resetModTimes(dst)
+}
@@ -0,0 +1,197 @@
+package gocovshtest
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/workspace"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/workspace",
+	}
+
+	t.Run("list", func(t *testing.T) {
+		initCmd := mt.init()
+		initMsg := initCmd()
+
+		mm, cmd := mt.sendWindowSizeMsg(60, 20)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		mm, cmd = mt.sendProfilesMsg(initMsg)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "workspace_list", []byte(mm.View()))
+	})
+
+	t.Run("file from another module", func(t *testing.T) {
+		// skip the header of the module
+		_, _ = mt.sendLetterKey('j')
+		mm, _ := mt.sendLetterKey('j')
+		require.NotNil(t, mm)
+
+		mm, cmd := mt.sendEnterKey()
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, cmd = mt.sendFileContentsMsg(cmd())
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "workspace_file", []byte(mm.View()))
+	})
+
+	t.Run("filter without module headers", func(t *testing.T) {
+		_, _ = mt.sendEscKey()
+
+		mm := mt.filter(".go")
+		g.Assert(t, "workspace_filtered", []byte(mm.View()))
+	})
+}
+
+func TestOtherModules(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/replace"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/replace/app",
+	}
+
+	t.Run("list", func(t *testing.T) {
+		initCmd := mt.init()
+		initMsg := initCmd()
+
+		mm, cmd := mt.sendWindowSizeMsg(70, 20)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		mm, cmd = mt.sendProfilesMsg(initMsg)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "replace_list", []byte(mm.View()))
+	})
+
+	openFile := func(t *testing.T, golden string) {
+		mm, cmd := mt.sendEnterKey()
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, cmd = mt.sendFileContentsMsg(cmd())
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, golden, []byte(mm.View()))
+
+		mm, _ = mt.sendEscKey()
+		require.NotNil(t, mm)
+	}
+
+	t.Run("replaced module", func(t *testing.T) {
+		mm, _ := mt.sendLetterKey('j')
+		require.NotNil(t, mm)
+
+		openFile(t, "replace_local")
+	})
+
+	t.Run("source not found", func(t *testing.T) {
+		mm, _ := mt.sendLetterKey('j')
+		require.NotNil(t, mm)
+
+		mm, cmd := mt.sendEnterKey()
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "replace_not_found", []byte(mm.View()))
+	})
+
+	t.Run("vendored module", func(t *testing.T) {
+		mm, _ := mt.sendLetterKey('j')
+		require.NotNil(t, mm)
+
+		openFile(t, "replace_vendor")
+	})
+}
+
+func TestOtherModulesReload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the go command")
+	}
+
+	// the go command only records that it was called
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "go"), []byte(script), 0o700)) // nolint: gosec
+	t.Setenv("PATH", bin)
+
+	dir := copyDir(t, "testdata/replace")
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        filepath.Join(dir, "app"),
+		watchInterval:   time.Millisecond,
+	}
+
+	initCmd := mt.init()
+
+	_, cmd := mt.sendWindowSizeMsg(70, 20)
+	require.Nil(t, cmd)
+
+	cmd = mt.process(initCmd)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "app", "coverage.out"), later, later))
+
+	// detect the change and reload the profiles
+	for i := 0; i < 2; i++ {
+		cmd = mt.process(cmd)
+	}
+
+	bs, err := os.ReadFile(calls) // nolint: gosec
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(bs), "\n"), "go command runs once: %s", bs)
+	require.Contains(t, string(bs), "list -e -json")
+	require.Contains(t, string(bs), "example.com/missing")
+	require.Contains(t, mt.m.View(), "source not found")
+}
+
+func TestGoModParsing(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/gomod"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/gomod",
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	mm, cmd := mt.sendWindowSizeMsg(60, 10)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	mm, cmd = mt.sendProfilesMsg(initMsg)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	require.Equal(t, "example.com/quoted", mt.m.ModuleName())
+	require.Equal(t, "1.19", mt.m.Modules()[0].GoVersion)
+
+	g.Assert(t, "gomod_list", []byte(mm.View()))
+}
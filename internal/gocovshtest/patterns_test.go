@@ -0,0 +1,90 @@
+package gocovshtest
+
+import (
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedFiles(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/generated"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/generated",
+	}
+
+	t.Run("hidden by default", func(t *testing.T) {
+		initCmd := mt.init()
+		initMsg := initCmd()
+
+		mm, cmd := mt.sendWindowSizeMsg(60, 14)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		mm, cmd = mt.sendProfilesMsg(initMsg)
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		g.Assert(t, "generated_hidden", []byte(mm.View()))
+	})
+
+	t.Run("toggle", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('x')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "generated_shown", []byte(mm.View()))
+
+		mm, cmd = mt.sendLetterKey('x')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "generated_hidden_again", []byte(mm.View()))
+	})
+}
+
+func TestPatterns(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/generated"))
+
+	tests := []struct {
+		name            string
+		includePatterns []string
+		excludePatterns []string
+	}{
+		{name: "exclude directory", excludePatterns: []string{"cmd/"}},
+		{name: "exclude anchored", excludePatterns: []string{"/cmd/*/main.go"}},
+		{name: "include glob", includePatterns: []string{"**/*.go"}, excludePatterns: []string{"service.go"}},
+		{name: "include directory", includePatterns: []string{"cmd/**", "api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := &modelTest{
+				T:               t,
+				profileFilename: "coverage.out",
+				codeRoot:        "testdata/generated",
+				includePatterns: tt.includePatterns,
+				excludePatterns: tt.excludePatterns,
+			}
+
+			initCmd := mt.init()
+			initMsg := initCmd()
+
+			mm, cmd := mt.sendWindowSizeMsg(60, 14)
+			require.NotNil(t, mm)
+			require.Nil(t, cmd)
+
+			mm, cmd = mt.sendProfilesMsg(initMsg)
+			require.NotNil(t, mm)
+			require.Nil(t, cmd)
+
+			mm, _ = mt.sendLetterKey('x')
+			require.NotNil(t, mm)
+
+			g.Assert(t, "patterns_"+t.Name()[len("TestPatterns/"):], []byte(mm.View()))
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+import "example.com/config"
+
+func main() {
+	println(config.Greet("tool"))
+}
@@ -0,0 +1,6 @@
+package doctor
+
+// Line had a longer body.
+func Line() int {
+	return 1
+}
@@ -0,0 +1,6 @@
+package doctor
+
+// Sum returns the sum of the numbers.
+func Sum(a, b int) int {
+	return a + b
+}
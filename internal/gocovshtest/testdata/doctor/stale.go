@@ -0,0 +1,6 @@
+package doctor
+
+// Diff returns the difference of the numbers.
+func Diff(a, b int) int {
+	return a - b
+}
@@ -0,0 +1,7 @@
+package shapes
+
+import "fmt"
+
+func Describe(s Square) string {
+	return fmt.Sprintf("square %d", s.side)
+}
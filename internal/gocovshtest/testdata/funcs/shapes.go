@@ -0,0 +1,30 @@
+package shapes
+
+type Square struct{ side int }
+
+func (s *Square) Area() int {
+	return s.side * s.side
+}
+
+func (s Square) Scale(k int) Square {
+	if k <= 0 {
+		return s
+	}
+
+	return Square{side: s.side * k}
+}
+
+func Sum(squares []*Square) int {
+	total := 0
+	each(squares, func(s *Square) {
+		total += s.Area()
+	})
+
+	return total
+}
+
+func each(squares []*Square, fn func(*Square)) {
+	for _, s := range squares {
+		fn(s)
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+import "example.com/generated"
+
+func main() {
+	println(generated.Greet("tool"))
+}
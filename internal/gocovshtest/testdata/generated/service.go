@@ -0,0 +1,10 @@
+package generated
+
+// Greet returns the greeting.
+func Greet(name string) string {
+	if name == "" {
+		return "hello"
+	}
+
+	return "hello, " + name
+}
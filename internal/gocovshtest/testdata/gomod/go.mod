@@ -0,0 +1,6 @@
+// module example.com/commented is the old name
+module "example.com/quoted" // the current name
+
+go 1.19
+
+exclude example.com/dep v1.0.1
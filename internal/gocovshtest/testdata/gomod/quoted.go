@@ -0,0 +1,6 @@
+package quoted
+
+// Quoted returns the value in quotes.
+func Quoted(s string) string {
+	return `"` + s + `"`
+}
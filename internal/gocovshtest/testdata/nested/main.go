@@ -0,0 +1,5 @@
+package nested
+
+func Main() string {
+	return "main"
+}
@@ -0,0 +1,5 @@
+package plugin
+
+func Plugin() string {
+	return "plugin"
+}
@@ -0,0 +1,11 @@
+module example.com/app
+
+go 1.19
+
+require (
+	example.com/dep v1.0.0
+	example.com/missing v0.1.0
+	example.com/vend v1.2.0
+)
+
+replace example.com/dep => ../dep
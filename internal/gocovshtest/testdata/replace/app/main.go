@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/dep"
+	"example.com/vend"
+)
+
+func main() {
+	fmt.Println(dep.Double(2), vend.Name())
+}
@@ -0,0 +1,10 @@
+package dep
+
+// Double returns twice the value.
+func Double(n int) int {
+	if n == 0 {
+		return 0
+	}
+
+	return n * 2
+}
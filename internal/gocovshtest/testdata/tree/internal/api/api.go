@@ -0,0 +1,6 @@
+package api
+
+// Serve is a test function.
+func Serve() int {
+	return 1
+}
@@ -0,0 +1,6 @@
+package store
+
+// Get is a test function.
+func Get() int {
+	return 1
+}
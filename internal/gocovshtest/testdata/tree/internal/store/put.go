@@ -0,0 +1,6 @@
+package store
+
+// Put is a test function.
+func Put() int {
+	return 1
+}
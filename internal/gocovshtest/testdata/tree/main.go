@@ -0,0 +1,6 @@
+package main
+
+// Run is a test function.
+func Run() int {
+	return 1
+}
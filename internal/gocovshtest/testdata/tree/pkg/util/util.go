@@ -0,0 +1,6 @@
+package util
+
+// Help is a test function.
+func Help() int {
+	return 1
+}
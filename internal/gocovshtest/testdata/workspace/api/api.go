@@ -0,0 +1,9 @@
+package api
+
+func Handle(ok bool) string {
+	if ok {
+		return "ok"
+	}
+
+	return "fail"
+}
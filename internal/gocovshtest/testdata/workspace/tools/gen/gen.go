@@ -0,0 +1,5 @@
+package gen
+
+func Generate() string {
+	return "generated"
+}
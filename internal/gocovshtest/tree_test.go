@@ -0,0 +1,193 @@
+package gocovshtest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeView(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/tree"))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        "testdata/tree",
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	mm, cmd := mt.sendWindowSizeMsg(60, 16)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	mm, cmd = mt.sendProfilesMsg(initMsg)
+	require.NotNil(t, mm)
+	require.Nil(t, cmd)
+
+	t.Run("collapsed", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('t')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "tree_collapsed", []byte(mm.View()))
+	})
+
+	t.Run("expand directory", func(t *testing.T) {
+		mm, _ := mt.sendEnterKey()
+		require.NotNil(t, mm)
+
+		g.Assert(t, "tree_expanded_dir", []byte(mm.View()))
+	})
+
+	t.Run("expand all", func(t *testing.T) {
+		mm, _ := mt.sendLetterKey('+')
+		require.NotNil(t, mm)
+
+		g.Assert(t, "tree_expanded_all", []byte(mm.View()))
+	})
+
+	t.Run("filter across the tree", func(t *testing.T) {
+		_, _ = mt.sendLetterKey('-')
+
+		mm := mt.filter("store/p")
+		g.Assert(t, "tree_filtered", []byte(mm.View()))
+	})
+
+	t.Run("open filtered file", func(t *testing.T) {
+		mm, cmd := mt.sendEnterKey()
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		mm, cmd = mt.sendFileContentsMsg(cmd())
+		require.NotNil(t, mm)
+		require.Nil(t, cmd)
+
+		_, _ = mt.sendEscKey()
+		mm, cmd = mt.sendEscKey()
+		mt.runCmd(cmd)
+
+		g.Assert(t, "tree_filter_cleared", []byte(mm.View()))
+	})
+
+	t.Run("open filtered directory", func(t *testing.T) {
+		_ = mt.filter("api/")
+
+		mm, cmd := mt.sendEnterKey()
+		mt.runCmd(cmd)
+
+		g.Assert(t, "tree_filtered_dir", []byte(mm.View()))
+	})
+
+	t.Run("flat view", func(t *testing.T) {
+		mm, cmd := mt.sendLetterKey('t')
+		require.NotNil(t, mm)
+		require.NotNil(t, cmd)
+
+		g.Assert(t, "tree_flat", []byte(mm.View()))
+	})
+}
+
+func TestTreeViewAbsolutePaths(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/tree"))
+
+	dir := t.TempDir()
+	profile := "mode: set\n" +
+		"/abs/dir/x.go:4.18,6.2 1 0\n" +
+		"/abs/dir/y.go:4.16,6.2 1 1\n" +
+		"/abs/z.go:4.16,6.2 1 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "coverage.out"), []byte(profile), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/abs\n"), 0o600))
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "coverage.out",
+		codeRoot:        dir,
+	}
+
+	initCmd := mt.init()
+	initMsg := initCmd()
+
+	_, _ = mt.sendWindowSizeMsg(60, 16)
+	_, _ = mt.sendProfilesMsg(initMsg)
+	_, _ = mt.sendLetterKey('t')
+
+	t.Run("expand all", func(t *testing.T) {
+		mm, _ := mt.sendLetterKey('+')
+		require.NotNil(t, mm)
+
+		g.Assert(t, "tree_absolute", []byte(mm.View()))
+	})
+
+	t.Run("open filtered directory", func(t *testing.T) {
+		_, _ = mt.sendLetterKey('-')
+		_ = mt.filter("dir/")
+
+		mm, cmd := mt.sendEnterKey()
+		mt.runCmd(cmd)
+
+		g.Assert(t, "tree_absolute_filtered_dir", []byte(mm.View()))
+	})
+}
+
+// filter types the filter and applies it.
+func (t *modelTest) filter(s string) tea.Model {
+	_, cmd := t.sendLetterKey('/')
+	t.runCmd(cmd)
+
+	for _, r := range s {
+		_, cmd = t.sendLetterKey(r)
+		t.runCmd(cmd)
+	}
+
+	mm, cmd := t.sendEnterKey()
+	t.runCmd(cmd)
+
+	return mm
+}
+
+// runCmd executes the command and its batched commands, and sends the
+// filtered items to the model. Other messages, such as the blinking of the
+// cursor, are ignored.
+func (t *modelTest) runCmd(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+
+	select {
+	case msg = <-done:
+	case <-time.After(100 * time.Millisecond):
+		return
+	}
+
+	switch msg := msg.(type) {
+	case list.FilterMatchesMsg:
+		_, _ = t.m.Update(msg)
+		return
+	case tea.KeyMsg:
+		return
+	}
+
+	// batched commands are not exported by bubbletea
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem() != reflect.TypeOf(tea.Cmd(nil)) {
+		return
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		t.runCmd(v.Index(i).Interface().(tea.Cmd))
+	}
+}
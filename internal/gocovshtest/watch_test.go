@@ -0,0 +1,158 @@
+package gocovshtest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	g := goldie.New(t, goldie.WithFixtureDir("testdata/watch"))
+	dir := copyGeneralTestdata(t)
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "profile.cover",
+		codeRoot:        dir,
+		watchInterval:   time.Millisecond,
+	}
+
+	initCmd := mt.init()
+
+	_, cmd := mt.sendWindowSizeMsg(60, 20)
+	require.Nil(t, cmd)
+
+	// profiles are loaded, and the initial state of the files is recorded
+	cmd = mt.process(initCmd)
+
+	_, _ = mt.sendLetterKey('j')
+	_, fileCmd := mt.sendEnterKey()
+	mt.process(fileCmd)
+
+	mm, _ := mt.sendLetterKey('G')
+	g.Assert(t, "watch_before_change", []byte(mm.View()))
+
+	// all the statements become covered
+	profile := filepath.Join(dir, "profile.cover")
+	bs, err := os.ReadFile(profile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(profile, []byte(strings.ReplaceAll(string(bs), " 0\n", " 1\n")), 0o600))
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(profile, later, later))
+
+	// detect the change, reload the profiles, then reload the open file
+	for i := 0; i < 4; i++ {
+		cmd = mt.process(cmd)
+	}
+
+	g.Assert(t, "watch_after_change", []byte(mt.m.View()))
+
+	mm, _ = mt.sendEscKey()
+	g.Assert(t, "watch_back_to_list", []byte(mm.View()))
+}
+
+func TestWatchReloadWhileUsing(t *testing.T) {
+	dir := copyGeneralTestdata(t)
+
+	mt := &modelTest{
+		T:               t,
+		profileFilename: "profile.cover",
+		codeRoot:        dir,
+		watchInterval:   time.Millisecond,
+	}
+
+	initCmd := mt.init()
+
+	_, cmd := mt.sendWindowSizeMsg(60, 20)
+	require.Nil(t, cmd)
+
+	cmd = mt.process(initCmd)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "profile.cover"), later, later))
+
+	// the change is detected, and the profiles are reloaded in the background
+	// while the keys are handled
+	reloadCmd := mt.process(cmd)
+	reloaded := make(chan []tea.Msg, 1)
+
+	go func() { reloaded <- runCmd(reloadCmd) }()
+
+	for i := 0; i < 10; i++ {
+		_, _ = mt.sendLetterKey('x')
+		_, _ = mt.sendEnterKey()
+		_, _ = mt.sendEscKey()
+	}
+
+	for _, msg := range <-reloaded {
+		_, _ = mt.m.Update(msg)
+	}
+
+	require.Contains(t, mt.m.View(), "covered.go")
+}
+
+// copyGeneralTestdata copies the general test data into a temporary
+// directory that can be changed by the test.
+func copyGeneralTestdata(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"go.mod", "profile.cover", "covered.go",
+		"partial_with_a_very_long_name_to_trigger_ellipsis_in_the_output.go",
+	} {
+		bs, err := os.ReadFile(filepath.Join("testdata", "general", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), bs, 0o600))
+	}
+
+	require.NoError(t, resetModTimes(dir))
+
+	return dir
+}
+
+// process runs the command, including all the batched commands, and sends
+// the resulting messages to the model. It returns the commands created while
+// processing the messages.
+func (t *modelTest) process(cmd tea.Cmd) tea.Cmd {
+	var cmds []tea.Cmd
+
+	for _, msg := range runCmd(cmd) {
+		_, next := t.m.Update(msg)
+		cmds = append(cmds, next)
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	msg := cmd()
+
+	// batches are handled by the tea.Program, and their type is not exported
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(cmd) {
+		var msgs []tea.Msg
+
+		for i := 0; i < v.Len(); i++ {
+			if sub, ok := v.Index(i).Interface().(tea.Cmd); ok {
+				msgs = append(msgs, runCmd(sub)...)
+			}
+		}
+
+		return msgs
+	}
+
+	return []tea.Msg{msg}
+}
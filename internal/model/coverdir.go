@@ -0,0 +1,460 @@
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Binary coverage data is written by the binaries built with "go build
+// -cover" into the directory set in GOCOVERDIR. The format is described in
+// the Go source tree, in "internal/coverage/defs.go". Every directory holds
+// meta-data files, one per binary, and counter files, one per execution.
+const (
+	coverMetaFilePrefix    = "covmeta."
+	coverCounterFilePrefix = "covcounters."
+
+	metaFileHeaderSize      = 56
+	metaSymbolHeaderSize    = 44
+	counterFileHeaderSize   = 32
+	counterFileFooterSize   = 16
+	counterSegmentHeaderLen = 16
+
+	counterFlavorRaw     = 1
+	counterFlavorULEB128 = 2
+
+	counterGranularityPerFunc = 2
+)
+
+var (
+	coverMetaMagic    = []byte{0, 'c', 'v', 'm'}
+	coverCounterMagic = []byte{0, 'c', 'w', 'm'}
+
+	coverModes = map[uint8]string{1: "set", 2: "count", 3: "atomic"}
+)
+
+// coverMeta is the decoded meta-data file of a single instrumented binary.
+type coverMeta struct {
+	mode        string
+	granularity uint8
+	packages    [][]coverFunc
+}
+
+type coverFunc struct {
+	file  string
+	units []coverUnit
+}
+
+type coverUnit struct {
+	startLine, startCol int
+	endLine, endCol     int
+	numStmt             int
+}
+
+// counterKey identifies a function in a meta-data file.
+type counterKey struct {
+	pkg, fn uint32
+}
+
+// readCoverDir converts the binary coverage data found in the directory
+// into profiles. Counters of all the executions are merged.
+func readCoverDir(dir string) ([]*cover.Profile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errNoCoverageDir{err}
+		}
+
+		return nil, errInvalidCoverageDir{err}
+	}
+
+	metas := map[string]*coverMeta{}
+	counters := map[string]map[counterKey][]uint32{}
+
+	for _, e := range entries {
+		name := e.Name()
+		filename := filepath.Join(dir, name)
+
+		switch {
+		case strings.HasPrefix(name, coverMetaFilePrefix):
+			meta, err := readCoverMetaFile(filename)
+			if err != nil {
+				return nil, errInvalidCoverageDir{fmt.Errorf("%s: %w", filename, err)}
+			}
+
+			metas[strings.TrimPrefix(name, coverMetaFilePrefix)] = meta
+
+		case strings.HasPrefix(name, coverCounterFilePrefix):
+			hash, fileCounters, err := readCoverCounterFile(filename)
+			if err != nil {
+				return nil, errInvalidCoverageDir{fmt.Errorf("%s: %w", filename, err)}
+			}
+
+			if counters[hash] == nil {
+				counters[hash] = map[counterKey][]uint32{}
+			}
+
+			for k, v := range fileCounters {
+				counters[hash][k] = mergeCounters(counters[hash][k], v)
+			}
+		}
+	}
+
+	if len(metas) == 0 {
+		return nil, errNoCoverageDir{fmt.Errorf("no coverage meta-data files in %s", dir)}
+	}
+
+	hashes := make([]string, 0, len(metas))
+	for hash := range metas {
+		hashes = append(hashes, hash)
+	}
+
+	sort.Strings(hashes)
+
+	profiles := &profileSet{}
+
+	for _, hash := range hashes {
+		if err := profiles.add(dir, metas[hash].profiles(counters[hash])); err != nil {
+			return nil, err
+		}
+	}
+
+	return profiles.list(), nil
+}
+
+// profiles combines the meta-data with the counters of the functions that
+// were executed. Functions without counters were never executed.
+func (m *coverMeta) profiles(counters map[counterKey][]uint32) []*cover.Profile {
+	var (
+		profiles []*cover.Profile
+		byName   = map[string]*cover.Profile{}
+	)
+
+	for pkgIdx, funcs := range m.packages {
+		for fnIdx, fn := range funcs {
+			p, ok := byName[fn.file]
+			if !ok {
+				p = &cover.Profile{FileName: fn.file, Mode: m.mode}
+				byName[fn.file] = p
+				profiles = append(profiles, p)
+			}
+
+			fnCounters := counters[counterKey{uint32(pkgIdx), uint32(fnIdx)}]
+
+			for i, u := range fn.units {
+				var count uint32
+
+				switch {
+				case m.granularity == counterGranularityPerFunc && len(fnCounters) > 0:
+					count = fnCounters[0]
+				case i < len(fnCounters):
+					count = fnCounters[i]
+				}
+
+				if m.mode == "set" && count > 0 {
+					count = 1
+				}
+
+				p.Blocks = append(p.Blocks, cover.ProfileBlock{
+					StartLine: u.startLine,
+					StartCol:  u.startCol,
+					EndLine:   u.endLine,
+					EndCol:    u.endCol,
+					NumStmt:   u.numStmt,
+					Count:     int(count),
+				})
+			}
+		}
+	}
+
+	for _, p := range profiles {
+		sortBlocks(p.Blocks)
+	}
+
+	return profiles
+}
+
+// mergeCounters sums the counters of the same function collected in
+// different executions.
+func mergeCounters(existing, counters []uint32) []uint32 {
+	if existing == nil {
+		return counters
+	}
+
+	for i, c := range counters {
+		if i < len(existing) {
+			existing[i] += c
+		}
+	}
+
+	return existing
+}
+
+func readCoverMetaFile(filename string) (*coverMeta, error) {
+	data, err := os.ReadFile(filename) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < metaFileHeaderSize || !bytes.Equal(data[:4], coverMetaMagic) {
+		return nil, fmt.Errorf("not a coverage meta-data file")
+	}
+
+	le := binary.LittleEndian
+	entries := le.Uint64(data[16:24])
+	meta := &coverMeta{
+		mode:        coverModes[data[48]],
+		granularity: data[49],
+	}
+
+	if meta.mode == "" {
+		return nil, fmt.Errorf("unknown coverage mode %d", data[48])
+	}
+
+	// every entry takes 16 bytes; the size is checked before multiplying to
+	// avoid an overflow
+	if entries > uint64(len(data))/16 || uint64(len(data)) < metaFileHeaderSize+entries*16 {
+		return nil, fmt.Errorf("truncated package table")
+	}
+
+	for i := uint64(0); i < entries; i++ {
+		offset := le.Uint64(data[metaFileHeaderSize+i*8:])
+		length := le.Uint64(data[metaFileHeaderSize+(entries+i)*8:])
+
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("package %d is out of bounds", i)
+		}
+
+		funcs, err := readCoverMetaPackage(data[offset : offset+length])
+		if err != nil {
+			return nil, fmt.Errorf("package %d: %w", i, err)
+		}
+
+		meta.packages = append(meta.packages, funcs)
+	}
+
+	return meta, nil
+}
+
+func readCoverMetaPackage(data []byte) ([]coverFunc, error) {
+	if len(data) < metaSymbolHeaderSize {
+		return nil, fmt.Errorf("truncated package header")
+	}
+
+	numFuncs := int(binary.LittleEndian.Uint32(data[40:44]))
+	if len(data) < metaSymbolHeaderSize+4*numFuncs {
+		return nil, fmt.Errorf("truncated function table")
+	}
+
+	r := &ulebReader{data: data, offset: metaSymbolHeaderSize + 4*numFuncs}
+	strs := r.strings()
+
+	funcs := make([]coverFunc, 0, numFuncs)
+
+	for i := 0; i < numFuncs; i++ {
+		r.offset = int(binary.LittleEndian.Uint32(data[metaSymbolHeaderSize+4*i:]))
+
+		numUnits := r.uint()
+		_ = r.uint() // function name
+		fileIdx := r.uint()
+
+		if fileIdx >= uint64(len(strs)) {
+			return nil, fmt.Errorf("invalid file name reference in function %d", i)
+		}
+
+		if numUnits > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid number of units in function %d", i)
+		}
+
+		fn := coverFunc{file: strs[fileIdx], units: make([]coverUnit, 0, numUnits)}
+
+		for j := uint64(0); j < numUnits; j++ {
+			fn.units = append(fn.units, coverUnit{
+				startLine: int(r.uint()),
+				startCol:  int(r.uint()),
+				endLine:   int(r.uint()),
+				endCol:    int(r.uint()),
+				numStmt:   int(r.uint()),
+			})
+		}
+
+		funcs = append(funcs, fn)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return funcs, nil
+}
+
+// readCoverCounterFile returns the hash of the meta-data file the counters
+// belong to, and the counters of every executed function.
+func readCoverCounterFile(filename string) (string, map[counterKey][]uint32, error) {
+	data, err := os.ReadFile(filename) // nolint: gosec
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(data) < counterFileHeaderSize+counterFileFooterSize || !bytes.Equal(data[:4], coverCounterMagic) {
+		return "", nil, fmt.Errorf("not a coverage counter file")
+	}
+
+	hash := fmt.Sprintf("%x", data[8:24])
+	flavor := data[24]
+	bigEndian := data[25] != 0
+	numSegments := binary.LittleEndian.Uint32(data[len(data)-8:])
+
+	r := &ulebReader{data: data, offset: counterFileHeaderSize}
+
+	readUint32 := r.uint32
+	if flavor == counterFlavorRaw {
+		readUint32 = func() uint32 { return r.raw32(bigEndian) }
+	} else if flavor != counterFlavorULEB128 {
+		return "", nil, fmt.Errorf("unknown counter flavor %d", flavor)
+	}
+
+	counters := map[counterKey][]uint32{}
+
+	for s := uint32(0); s < numSegments; s++ {
+		if s > 0 {
+			r.offset += counterFileFooterSize
+		}
+
+		if r.offset+counterSegmentHeaderLen > len(data) {
+			return "", nil, fmt.Errorf("truncated segment %d", s)
+		}
+
+		fcnEntries := binary.LittleEndian.Uint64(data[r.offset:])
+		strTabLen := int(binary.LittleEndian.Uint32(data[r.offset+8:]))
+		argsLen := int(binary.LittleEndian.Uint32(data[r.offset+12:]))
+
+		r.offset += counterSegmentHeaderLen + strTabLen + argsLen
+		if rem := r.offset % 4; rem != 0 {
+			r.offset += 4 - rem
+		}
+
+		for f := uint64(0); f < fcnEntries; f++ {
+			numCounters := int(readUint32())
+			key := counterKey{pkg: readUint32(), fn: readUint32()}
+
+			if numCounters > len(data) {
+				return "", nil, fmt.Errorf("invalid number of counters %d", numCounters)
+			}
+
+			values := make([]uint32, numCounters)
+			for i := range values {
+				values[i] = readUint32()
+			}
+
+			if r.err != nil {
+				return "", nil, r.err
+			}
+
+			counters[key] = mergeCounters(counters[key], values)
+		}
+	}
+
+	return hash, counters, nil
+}
+
+// ulebReader reads the variable length integers and string tables used in
+// binary coverage data. The first error stops further reading.
+type ulebReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *ulebReader) uint() uint64 {
+	var (
+		value uint64
+		shift uint
+	)
+
+	for {
+		if r.err != nil {
+			return 0
+		}
+
+		if r.offset >= len(r.data) {
+			r.err = fmt.Errorf("unexpected end of data")
+			return 0
+		}
+
+		b := r.data[r.offset]
+		r.offset++
+
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+
+		shift += 7
+	}
+}
+
+func (r *ulebReader) uint32() uint32 {
+	return uint32(r.uint())
+}
+
+func (r *ulebReader) raw32(bigEndian bool) uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.offset+4 > len(r.data) {
+		r.err = fmt.Errorf("unexpected end of data")
+		return 0
+	}
+
+	b := r.data[r.offset : r.offset+4]
+	r.offset += 4
+
+	if bigEndian {
+		return binary.BigEndian.Uint32(b)
+	}
+
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *ulebReader) strings() []string {
+	// every string takes at least one byte for its length, so the remaining
+	// data limits the number of strings of a corrupted table
+	n := r.uint()
+	if remaining := r.remaining(); n > remaining {
+		n = remaining
+	}
+
+	strs := make([]string, 0, n)
+
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		length := r.uint()
+
+		if length > r.remaining() {
+			r.err = fmt.Errorf("unexpected end of data")
+			break
+		}
+
+		strs = append(strs, string(r.data[r.offset:r.offset+int(length)]))
+		r.offset += int(length)
+	}
+
+	return strs
+}
+
+// remaining returns the number of bytes left to read.
+func (r *ulebReader) remaining() uint64 {
+	if r.offset >= len(r.data) {
+		return 0
+	}
+
+	return uint64(len(r.data) - r.offset)
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/tools/cover"
+)
+
+// ProblemKind is the category of the problem of a profile entry.
+type ProblemKind string
+
+// Problems found by the checks of the profile entries.
+const (
+	ProblemMissingFile    ProblemKind = "missing file"
+	ProblemModifiedSource ProblemKind = "modified source"
+	ProblemInvalidBlock   ProblemKind = "invalid block"
+	ProblemUnknownModule  ProblemKind = "unknown module"
+)
+
+// badge is the short label of the problem shown in the list.
+func (k ProblemKind) badge() string {
+	switch k {
+	case ProblemMissingFile:
+		return "source not found"
+	case ProblemModifiedSource:
+		return "modified"
+	case ProblemInvalidBlock:
+		return "mismatch"
+	default:
+		return string(k)
+	}
+}
+
+// Problem describes why the coverage of a file can't be trusted, and how to
+// fix it.
+type Problem struct {
+	FileName string
+	Kind     ProblemKind
+	Details  string
+	Fix      string
+}
+
+// DiagnoseProfiles reads every entry of the profiles, including the files
+// that are not requested, excluded, outside of the subdirectory or generated,
+// and returns them with their problems, in the order of the profiles. Only
+// the first problem of every file is reported.
+func (m *Model) DiagnoseProfiles() ([]*cover.Profile, []Problem, error) {
+	if m.inputErr != nil {
+		return nil, nil, m.inputErr
+	}
+
+	loaded, err := m.readProfiles(m.codeRoot, m.profileFilenames, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(loaded.profiles) == 0 {
+		return nil, nil, errNoProfiles{errors.New("no coverage data")}
+	}
+
+	var problems []Problem
+
+	for _, p := range loaded.profiles {
+		if problem, ok := loaded.problems[p.FileName]; ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	return loaded.profiles, problems, nil
+}
+
+// diagnose checks the profile entry against its source code: the file must
+// exist, must not change after the profiles were written, and every block
+// must point inside the file.
+func (l *loadedProfiles) diagnose(p *cover.Profile, profilesModTime time.Time) (Problem, bool) {
+	problem := Problem{FileName: p.FileName}
+
+	if l.unknownModules[p.FileName] {
+		problem.Kind = ProblemUnknownModule
+		problem.Details = "the file does not belong to the modules of the code root, and no go.mod file requires its module"
+		problem.Fix = `set "--root" to the module the profile was written for, or regenerate the profile in this module`
+
+		return problem, true
+	}
+
+	filename := l.sourceFile(p.FileName)
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		problem.Kind = ProblemMissingFile
+		problem.Details = err.Error()
+
+		if errors.Is(err, fs.ErrNotExist) {
+			problem.Details = filepath.ToSlash(filename) + " does not exist"
+		}
+
+		problem.Fix = "run the tests again if the file was moved or deleted"
+
+		if l.unresolvedFiles[p.FileName] {
+			problem.Details = "the source code of the module is not in the module cache or the vendor directory"
+			problem.Fix = `run "go mod download" to fetch the module`
+		}
+
+		return problem, true
+	}
+
+	lines, err := ReadLines(filename)
+	if err != nil {
+		problem.Kind = ProblemMissingFile
+		problem.Details = err.Error()
+		problem.Fix = "check the permissions of the file"
+
+		return problem, true
+	}
+
+	if details, ok := checkBlocks(lines, p.Blocks); !ok {
+		problem.Kind = ProblemInvalidBlock
+		problem.Details = details
+		problem.Fix = "the source code changed after the profile was written; run the tests again"
+
+		return problem, true
+	}
+
+	if !profilesModTime.IsZero() && fi.ModTime().After(profilesModTime) {
+		problem.Kind = ProblemModifiedSource
+		problem.Details = fmt.Sprintf(
+			"the file was modified at %s, after the coverage data was written at %s",
+			fi.ModTime().Format(time.RFC3339), profilesModTime.Format(time.RFC3339),
+		)
+		problem.Fix = "run the tests again to update the coverage data"
+
+		return problem, true
+	}
+
+	return problem, false
+}
+
+// checkBlocks makes sure that every block starts and ends inside the lines of
+// the source code, and describes the first block that does not.
+func checkBlocks(lines []string, blocks []cover.ProfileBlock) (string, bool) {
+	invalid := 0
+	details := ""
+
+	for _, b := range blocks {
+		position := fmt.Sprintf("block %d.%d,%d.%d", b.StartLine, b.StartCol, b.EndLine, b.EndCol)
+		problem := ""
+
+		switch {
+		case b.StartLine < 1 || b.StartCol < 1 || b.EndLine < b.StartLine:
+			problem = position + " is invalid"
+		case b.EndLine > len(lines):
+			problem = fmt.Sprintf("%s points past the end of the file with %d lines", position, len(lines))
+		case b.StartCol > len(lines[b.StartLine-1])+1:
+			problem = fmt.Sprintf("%s points past the end of line %d", position, b.StartLine)
+		case b.EndCol > len(lines[b.EndLine-1])+1:
+			problem = fmt.Sprintf("%s points past the end of line %d", position, b.EndLine)
+		}
+
+		if problem == "" {
+			continue
+		}
+
+		if invalid == 0 {
+			details = problem
+		}
+
+		invalid++
+	}
+
+	if invalid > 1 {
+		details += fmt.Sprintf(", and %d more blocks", invalid-1)
+	}
+
+	return details, invalid == 0
+}
+
+// profilesModTime returns the time the newest coverage data was written. It
+// is zero when the time is unknown.
+func profilesModTime(codeRoot string, filenames, coverDirs []string) time.Time {
+	var newest time.Time
+
+	check := func(name string) {
+		if fi, err := os.Stat(name); err == nil && fi.ModTime().After(newest) {
+			newest = fi.ModTime()
+		}
+	}
+
+	for _, filename := range filenames {
+		matches, err := expandProfileFilename(resolvePath(codeRoot, filename))
+		if err != nil {
+			continue
+		}
+
+		for _, match := range matches {
+			check(match)
+		}
+	}
+
+	for _, dir := range coverDirs {
+		entries, err := os.ReadDir(resolvePath(codeRoot, dir))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			check(filepath.Join(resolvePath(codeRoot, dir), entry.Name()))
+		}
+	}
+
+	return newest
+}
@@ -18,6 +18,15 @@ Update the coverage report and try again.`
 }
 func (e errInvalidCoverageFile) OriginalError() error { return e }
 
+type errConflictingModes struct{ error }
+
+func (e errConflictingModes) Title() string { return "Coverage reports can't be merged" }
+func (e errConflictingModes) Description() string {
+	return `The provided coverage reports were generated with different coverage modes.
+Generate all the reports with the same "-covermode" flag and try again.`
+}
+func (e errConflictingModes) OriginalError() error { return e }
+
 type errNoProfiles struct{ error }
 
 func (e errNoProfiles) Title() string { return "No coverage data" }
@@ -27,12 +36,22 @@ Try adding tests and generating the report again.`
 }
 func (e errNoProfiles) OriginalError() error { return nil }
 
+type errInvalidInput struct{ error }
+
+func (e errInvalidInput) Title() string { return "Invalid input" }
+func (e errInvalidInput) Description() string {
+	return `The diff or the list of files can't be parsed.
+Provide the output of "git diff", "git diff --name-only" or "git diff --name-status".`
+}
+func (e errInvalidInput) OriginalError() error { return e }
+
 type errGoModNotFound struct{ error }
 
 func (e errGoModNotFound) Title() string { return "go.mod file is not available" }
 func (e errGoModNotFound) Description() string {
-	return `This program must be executed from Go project root.
-Run the program from the folder that contains go.mod file.`
+	return `This program must be executed inside a Go project.
+Run the program from the folder that contains go.mod or go.work file,
+or from any of its subfolders. For other folders, use "--root" flag.`
 }
 func (e errGoModNotFound) OriginalError() error { return e }
 
@@ -40,9 +59,17 @@ type errInvalidGoMod struct{ error }
 
 func (e errInvalidGoMod) Title() string { return "Invalid go.mod file" }
 func (e errInvalidGoMod) Description() string {
-	return "go.mod file does not include a valid Go module name: `module ...`"
+	return "go.mod file can't be parsed or does not include a valid Go module name: `module ...`"
+}
+func (e errInvalidGoMod) OriginalError() error { return e }
+
+type errInvalidGoWork struct{ error }
+
+func (e errInvalidGoWork) Title() string { return "Invalid go.work file" }
+func (e errInvalidGoWork) Description() string {
+	return "go.work file was found, but it can't be read or doesn't use any modules: `use ./module`"
 }
-func (e errInvalidGoMod) OriginalError() error { return nil }
+func (e errInvalidGoWork) OriginalError() error { return e }
 
 type errSourceFileNotFound struct{ error }
 
@@ -66,6 +93,24 @@ type errMismatchingProfile struct{ error }
 func (e errMismatchingProfile) Title() string { return "Coverage data doesn't match the source" }
 func (e errMismatchingProfile) Description() string {
 	return `Coverage data cannot be applied to the existing source code.
-Update the coverage report and try again.`
+Update the coverage report and try again, or run "gocovsh doctor" to check all the files.`
 }
 func (e errMismatchingProfile) OriginalError() error { return e }
+
+type errNoCoverageDir struct{ error }
+
+func (e errNoCoverageDir) Title() string { return "Coverage data directory not found" }
+func (e errNoCoverageDir) Description() string {
+	return `Requested coverage data directory is not found or doesn't have any coverage data.
+Run a binary built with "go build -cover" with GOCOVERDIR set to this directory.`
+}
+func (e errNoCoverageDir) OriginalError() error { return e }
+
+type errInvalidCoverageDir struct{ error }
+
+func (e errInvalidCoverageDir) Title() string { return "Invalid coverage data directory" }
+func (e errInvalidCoverageDir) Description() string {
+	return `The provided coverage data directory was found, but can't be parsed.
+Collect the coverage data again and retry.`
+}
+func (e errInvalidCoverageDir) OriginalError() error { return e }
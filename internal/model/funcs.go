@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// FuncCoverage is the coverage of a single function or method declared in a
+// source code file. Function literals are attributed to the function that
+// declares them.
+type FuncCoverage struct {
+	// Name is the function name. Methods are prefixed with the receiver
+	// type, for example "(*Model).View" or "Model.View".
+	Name string
+
+	StartLine, StartCol int
+	EndLine, EndCol     int
+
+	Statements int64
+	Covered    int64
+
+	// Count is the number of times the function was entered, based on the
+	// count of its first block.
+	Count int
+}
+
+// Percentage returns the statement coverage of the function.
+func (f *FuncCoverage) Percentage() float64 {
+	return Percent(f.Covered, f.Statements)
+}
+
+// Funcs parses the source code file and returns the coverage of every
+// function declared in it, in the order of declaration.
+func Funcs(filename string, p *cover.Profile) ([]FuncCoverage, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	var funcs []FuncCoverage
+
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		fc := FuncCoverage{
+			Name:      funcName(fn),
+			StartLine: start.Line,
+			StartCol:  start.Column,
+			EndLine:   end.Line,
+			EndCol:    end.Column,
+		}
+
+		fc.collect(p.Blocks)
+		funcs = append(funcs, fc)
+	}
+
+	return funcs, nil
+}
+
+func (f *FuncCoverage) collect(blocks []cover.ProfileBlock) {
+	entered := false
+
+	for _, b := range blocks {
+		if !f.contains(b) {
+			continue
+		}
+
+		if !entered {
+			f.Count = b.Count
+			entered = true
+		}
+
+		f.Statements += int64(b.NumStmt)
+
+		if b.Count > 0 {
+			f.Covered += int64(b.NumStmt)
+		}
+	}
+}
+
+func (f *FuncCoverage) contains(b cover.ProfileBlock) bool {
+	startsAfter := b.StartLine > f.StartLine || (b.StartLine == f.StartLine && b.StartCol >= f.StartCol)
+	endsBefore := b.EndLine < f.EndLine || (b.EndLine == f.EndLine && b.EndCol <= f.EndCol)
+
+	return startsAfter && endsBefore
+}
+
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := receiverType(fn.Recv.List[0].Type)
+	if strings.HasPrefix(recv, "*") {
+		return fmt.Sprintf("(%s).%s", recv, fn.Name.Name)
+	}
+
+	return fmt.Sprintf("%s.%s", recv, fn.Name.Name)
+}
+
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return "?"
+	}
+}
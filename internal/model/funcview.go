@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/orlangure/gocovsh/internal/styles"
+)
+
+// funcItem is a function of the functions view, with the file it is declared
+// in.
+type funcItem struct {
+	file *coverProfile
+	fn   FuncCoverage
+}
+
+func (f *funcItem) FilterValue() string { return f.file.profile.FileName + " " + f.fn.Name }
+
+func (f *funcItem) key() string {
+	return fmt.Sprintf("%s:%d", f.file.profile.FileName, f.fn.StartLine)
+}
+
+// loadedFuncs are the functions of all the listed files, in the order of the
+// files and then of the declarations.
+type loadedFuncs []list.Item
+
+type funcDelegate struct{}
+
+func (d funcDelegate) Height() int                             { return 1 }
+func (d funcDelegate) Spacing() int                            { return 0 }
+func (d funcDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d funcDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(*funcItem)
+	if !ok {
+		return
+	}
+
+	inactiveColor := lipgloss.Color(styles.CurrentTheme.InactiveColor)
+	text := fmt.Sprintf("%.2f%% %s:%d", item.fn.Percentage(), item.file.profile.FileName, item.fn.StartLine)
+	line := fmt.Sprintf("%s %s", item.fn.Name, percentageStyle.Foreground(inactiveColor).Render(text))
+
+	if index == m.Index() {
+		line = selectedItemStyle.Foreground(lipgloss.Color(styles.CurrentTheme.PrimaryColor)).Render("> " + line)
+	} else {
+		line = itemStyle.Render(line)
+	}
+
+	fmt.Fprint(w, line)
+}
+
+func (m *Model) isFuncsView() bool {
+	return m.activeView == activeViewFuncs
+}
+
+// toggleFuncs switches between the files and the functions views. The
+// functions are loaded again every time the view is shown, so that they
+// follow the listed files.
+func (m *Model) toggleFuncs() tea.Cmd {
+	if m.isFuncsView() {
+		m.activeView = activeViewList
+		return nil
+	}
+
+	m.activeView = activeViewFuncs
+
+	return m.loadFuncs()
+}
+
+// loadFuncs parses the source code of the listed files, and maps their
+// coverage blocks onto the declared functions. Files that can't be parsed are
+// skipped.
+func (m *Model) loadFuncs() tea.Cmd {
+	files := make([]*coverProfile, 0, len(m.items))
+	filenames := make([]string, 0, len(m.items))
+
+	for _, item := range m.items {
+		f := item.(*coverProfile)
+		if f.unresolved {
+			continue
+		}
+
+		files = append(files, f)
+		filenames = append(filenames, m.sourceFile(f.profile.FileName))
+	}
+
+	return func() tea.Msg {
+		var items loadedFuncs
+
+		for i, f := range files {
+			funcs, err := Funcs(filenames[i], f.profile)
+			if err != nil {
+				log.Println("failed to find functions:", err)
+				continue
+			}
+
+			for _, fn := range funcs {
+				items = append(items, &funcItem{file: f, fn: fn})
+			}
+		}
+
+		return items
+	}
+}
+
+func (m *Model) onFuncsLoaded(items loadedFuncs) (tea.Model, tea.Cmd) {
+	m.funcs = items
+
+	return m, m.updateFuncItems()
+}
+
+// updateFuncItems sorts the functions and keeps the selection on the same
+// function.
+func (m *Model) updateFuncItems() tea.Cmd {
+	var selected string
+	if item, ok := m.funcList.SelectedItem().(*funcItem); ok {
+		selected = item.key()
+	}
+
+	items := make([]list.Item, len(m.funcs))
+	copy(items, m.funcs)
+
+	if m.funcsByCoverage {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].(*funcItem).fn.Percentage() < items[j].(*funcItem).fn.Percentage()
+		})
+	}
+
+	cmd := m.funcList.SetItems(items)
+
+	if selected == "" || m.funcList.FilterState() != list.Unfiltered {
+		return cmd
+	}
+
+	for i, item := range items {
+		if item.(*funcItem).key() == selected {
+			m.funcList.Select(i)
+		}
+	}
+
+	return cmd
+}
+
+// toggleFuncsSort orders the functions by their coverage, or by their
+// position in the files.
+func (m *Model) toggleFuncsSort() tea.Cmd {
+	m.funcsByCoverage = !m.funcsByCoverage
+
+	status := "Sorted by position"
+	if m.funcsByCoverage {
+		status = "Sorted by coverage"
+	}
+
+	return tea.Batch(m.updateFuncItems(), m.funcList.NewStatusMessage(status))
+}
+
+// openFunc opens the file of the function, scrolled to its declaration.
+func (m *Model) openFunc(item *funcItem) tea.Cmd {
+	m.codeParent = activeViewFuncs
+	m.scrollToLine = item.fn.StartLine
+
+	return m.openProfile(item.file)
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// goMod is the parsed go.mod file of a module.
+type goMod struct {
+	// modulePath is the path declared in the module directive.
+	modulePath string
+
+	// goVersion is the version declared in the go directive, if any.
+	goVersion string
+
+	// requires are the versions of the required modules, by module path.
+	requires map[string]string
+
+	// replaces are the replacements of the modules, by module path.
+	replaces map[string]replacement
+
+	// excludes are the excluded versions of the modules, by module path.
+	excludes map[string][]string
+}
+
+// replacement is the target of a replace directive: either a local directory,
+// or another module version.
+type replacement struct {
+	// oldVersion is set when only this version of the module is replaced.
+	oldVersion string
+
+	path    string
+	version string
+}
+
+func (r replacement) isLocal() bool {
+	return r.version == "" && modfile.IsDirectoryPath(r.path)
+}
+
+// appliesTo tells whether the replacement is used for the version of the
+// module.
+func (r replacement) appliesTo(version string) bool {
+	return r.oldVersion == "" || r.oldVersion == version
+}
+
+// readGoMod parses the go.mod file of a main module. Files with unknown
+// directives, written for newer Go versions, are parsed again leniently; the
+// replace and exclude directives are lost in this case.
+func readGoMod(gomodFile string) (*goMod, error) {
+	bs, err := os.ReadFile(gomodFile) // nolint: gosec
+	if err != nil {
+		return nil, errGoModNotFound{err}
+	}
+
+	f, err := modfile.Parse(gomodFile, bs, nil)
+	if err != nil {
+		if f, err = modfile.ParseLax(gomodFile, bs, nil); err != nil {
+			return nil, errInvalidGoMod{err}
+		}
+	}
+
+	if f.Module == nil || f.Module.Mod.Path == "" {
+		return nil, errInvalidGoMod{errors.New(gomodFile + ": module directive is missing")}
+	}
+
+	mod := &goMod{
+		modulePath: f.Module.Mod.Path,
+		requires:   make(map[string]string, len(f.Require)),
+		replaces:   make(map[string]replacement, len(f.Replace)),
+		excludes:   make(map[string][]string, len(f.Exclude)),
+	}
+
+	if f.Go != nil {
+		mod.goVersion = f.Go.Version
+	}
+
+	for _, r := range f.Require {
+		mod.requires[r.Mod.Path] = r.Mod.Version
+	}
+
+	for _, r := range f.Replace {
+		mod.replaces[r.Old.Path] = replacement{
+			oldVersion: r.Old.Version,
+			path:       r.New.Path,
+			version:    r.New.Version,
+		}
+	}
+
+	for _, e := range f.Exclude {
+		mod.excludes[e.Mod.Path] = append(mod.excludes[e.Mod.Path], e.Mod.Version)
+	}
+
+	return mod, nil
+}
+
+// isExcluded tells whether the version of the module is excluded.
+func (m *goMod) isExcluded(modulePath, version string) bool {
+	for _, v := range m.excludes[modulePath] {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moduleOf returns the required or replaced module that provides the file,
+// and the file name relative to the module root. The longest module path
+// wins.
+func (m *goMod) moduleOf(fileName string) (string, string, bool) {
+	var found string
+
+	check := func(modulePath string) {
+		if strings.HasPrefix(fileName, modulePath+"/") && len(modulePath) > len(found) {
+			found = modulePath
+		}
+	}
+
+	for modulePath := range m.requires {
+		check(modulePath)
+	}
+
+	for modulePath := range m.replaces {
+		check(modulePath)
+	}
+
+	if found == "" {
+		return "", "", false
+	}
+
+	return found, strings.TrimPrefix(fileName, found+"/"), true
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadGoMod(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replace and exclude", func(t *testing.T) {
+		t.Parallel()
+
+		gomodFile := writeGoMod(t, `module example.com/app
+
+go 1.19
+
+require (
+	example.com/dep v1.0.0
+	example.com/old v1.1.0
+)
+
+replace example.com/dep => ../dep
+
+replace example.com/old v1.1.0 => example.com/new v1.2.0
+
+exclude example.com/old v1.0.0
+`)
+
+		mod, err := readGoMod(gomodFile)
+		require.NoError(t, err)
+		require.Equal(t, "example.com/app", mod.modulePath)
+		require.Equal(t, "1.19", mod.goVersion)
+		require.Equal(t, map[string]string{
+			"example.com/dep": "v1.0.0",
+			"example.com/old": "v1.1.0",
+		}, mod.requires)
+		require.Equal(t, map[string]replacement{
+			"example.com/dep": {path: "../dep"},
+			"example.com/old": {oldVersion: "v1.1.0", path: "example.com/new", version: "v1.2.0"},
+		}, mod.replaces)
+		require.Equal(t, map[string][]string{"example.com/old": {"v1.0.0"}}, mod.excludes)
+		require.True(t, mod.isExcluded("example.com/old", "v1.0.0"))
+		require.False(t, mod.isExcluded("example.com/old", "v1.1.0"))
+	})
+
+	t.Run("unknown directives", func(t *testing.T) {
+		t.Parallel()
+
+		gomodFile := writeGoMod(t, `module example.com/app
+
+go 1.19
+
+future v1
+
+require example.com/dep v1.0.0
+`)
+
+		mod, err := readGoMod(gomodFile)
+		require.NoError(t, err)
+		require.Equal(t, "example.com/app", mod.modulePath)
+		require.Equal(t, map[string]string{"example.com/dep": "v1.0.0"}, mod.requires)
+	})
+}
+
+func writeGoMod(t *testing.T, content string) string {
+	t.Helper()
+
+	gomodFile := filepath.Join(t.TempDir(), "go.mod")
+	require.NoError(t, os.WriteFile(gomodFile, []byte(content), 0o600))
+
+	return gomodFile
+}
@@ -0,0 +1,236 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/orlangure/gocovsh/internal/codeview"
+)
+
+// Names of the actions that can be bound to other keys using
+// WithKeyBindings.
+const (
+	KeyActionOpen            = "open"
+	KeyActionBack            = "back"
+	KeyActionQuit            = "quit"
+	KeyActionHelp            = "help"
+	KeyActionToggleGenerated = "toggle-generated"
+	KeyActionToggleTree      = "toggle-tree"
+	KeyActionExpandAll       = "expand-all"
+	KeyActionCollapseAll     = "collapse-all"
+	KeyActionToggleFuncs     = "toggle-funcs"
+	KeyActionToggleSort      = "toggle-sort"
+)
+
+// KeyActions are all the actions that can be bound to other keys.
+var KeyActions = []string{
+	KeyActionOpen,
+	KeyActionBack,
+	KeyActionQuit,
+	KeyActionHelp,
+	KeyActionToggleGenerated,
+	KeyActionToggleTree,
+	KeyActionExpandAll,
+	KeyActionCollapseAll,
+	KeyActionToggleFuncs,
+	KeyActionToggleSort,
+}
+
+// keyMap holds the key bindings of the actions handled by the model itself;
+// the navigation keys belong to the list and the code views.
+type keyMap struct {
+	Open            key.Binding
+	Back            key.Binding
+	Quit            key.Binding
+	Help            key.Binding
+	ToggleGenerated key.Binding
+	ToggleTree      key.Binding
+	ExpandAll       key.Binding
+	CollapseAll     key.Binding
+	ToggleFuncs     key.Binding
+	ToggleSort      key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "more"),
+		),
+		ToggleGenerated: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "generated"),
+		),
+		ToggleTree: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "tree"),
+		),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "expand"),
+		),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse"),
+		),
+		ToggleFuncs: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "functions"),
+		),
+		ToggleSort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort"),
+		),
+	}
+}
+
+// DefaultKeyBindings returns the default keys of every action.
+func DefaultKeyBindings() map[string][]string {
+	km := defaultKeyMap()
+	bindings := make(map[string][]string, len(KeyActions))
+
+	for _, action := range KeyActions {
+		bindings[action] = km.binding(action).Keys()
+	}
+
+	return bindings
+}
+
+// CheckKeyBindings reports the keys bound to two actions, and the keys of the
+// actions that are used by the lists or the code view. The model handles its
+// keys first, so such a binding would take over the navigation key.
+func CheckKeyBindings(bindings map[string][]string) error {
+	reserved := reservedKeys()
+	bound := map[string]string{}
+
+	for _, action := range KeyActions {
+		for _, k := range bindings[action] {
+			if other, ok := reserved[k]; ok {
+				return fmt.Errorf("key %q is bound to both %q and %s", k, action, other)
+			}
+
+			if other, ok := bound[k]; ok && other != action {
+				return fmt.Errorf("key %q is bound to both %q and %q", k, other, action)
+			}
+
+			bound[k] = action
+		}
+	}
+
+	return nil
+}
+
+// reservedKeys returns the navigation keys of the lists and the code view,
+// with the names of their actions. The keys replaced by the model, such as
+// quit and help, are not included.
+func reservedKeys() map[string]string {
+	lk := list.DefaultKeyMap()
+	ck := codeview.DefaultKeyMap
+	vk := viewport.DefaultKeyMap()
+
+	bindings := []struct {
+		name    string
+		binding key.Binding
+	}{
+		{`"up" of the list`, lk.CursorUp},
+		{`"down" of the list`, lk.CursorDown},
+		{`"previous page" of the list`, lk.PrevPage},
+		{`"next page" of the list`, lk.NextPage},
+		{`"go to start" of the list`, lk.GoToStart},
+		{`"go to end" of the list`, lk.GoToEnd},
+		{`"filter" of the list`, lk.Filter},
+		{`"up" of the code view`, ck.Up},
+		{`"down" of the code view`, ck.Down},
+		{`"top" of the code view`, ck.Home},
+		{`"bottom" of the code view`, ck.End},
+		{`"half screen down" of the code view`, ck.HalfScreenDown},
+		{`"half screen up" of the code view`, ck.HalfScreenUp},
+		{`"page down" of the code view`, vk.PageDown},
+		{`"page up" of the code view`, vk.PageUp},
+		{`"half screen down" of the code view`, vk.HalfPageDown},
+		{`"half screen up" of the code view`, vk.HalfPageUp},
+	}
+
+	reserved := map[string]string{}
+
+	for _, b := range bindings {
+		for _, k := range b.binding.Keys() {
+			if _, ok := reserved[k]; !ok {
+				reserved[k] = b.name
+			}
+		}
+	}
+
+	return reserved
+}
+
+func (k *keyMap) binding(action string) *key.Binding {
+	switch action {
+	case KeyActionOpen:
+		return &k.Open
+	case KeyActionBack:
+		return &k.Back
+	case KeyActionQuit:
+		return &k.Quit
+	case KeyActionHelp:
+		return &k.Help
+	case KeyActionToggleGenerated:
+		return &k.ToggleGenerated
+	case KeyActionToggleTree:
+		return &k.ToggleTree
+	case KeyActionExpandAll:
+		return &k.ExpandAll
+	case KeyActionCollapseAll:
+		return &k.CollapseAll
+	case KeyActionToggleFuncs:
+		return &k.ToggleFuncs
+	case KeyActionToggleSort:
+		return &k.ToggleSort
+	}
+
+	return nil
+}
+
+// bind replaces the keys of the action; the first key is shown in the help.
+// Unknown actions and empty key lists are ignored.
+func (k *keyMap) bind(action string, keys []string) {
+	b := k.binding(action)
+	if b == nil || len(keys) == 0 {
+		return
+	}
+
+	b.SetKeys(keys...)
+	b.SetHelp(keys[0], b.Help().Desc)
+}
+
+// applyKeyMap makes the lists and the code view use the same keys as the
+// model, so that their help sections are up to date.
+func (m *Model) applyKeyMap() {
+	for _, l := range []*list.Model{&m.list, &m.funcList} {
+		l.KeyMap.Quit.SetKeys(m.keys.Quit.Keys()...)
+		l.KeyMap.Quit.SetHelp(m.keys.Quit.Help().Key, l.KeyMap.Quit.Help().Desc)
+		l.KeyMap.ShowFullHelp.SetKeys(m.keys.Help.Keys()...)
+		l.KeyMap.ShowFullHelp.SetHelp(m.keys.Help.Help().Key, l.KeyMap.ShowFullHelp.Help().Desc)
+		l.KeyMap.CloseFullHelp.SetKeys(m.keys.Help.Keys()...)
+		l.KeyMap.CloseFullHelp.SetHelp(m.keys.Help.Help().Key, l.KeyMap.CloseFullHelp.Help().Desc)
+	}
+
+	codeKeys := codeview.DefaultKeyMap
+	codeKeys.Back = m.keys.Back
+	codeKeys.Quit = m.keys.Quit
+	m.code.SetKeyMap(codeKeys)
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"io"
+	"path"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,23 +26,102 @@ var (
 type coverProfile struct {
 	profile    *cover.Profile
 	percentage float64
+
+	// inPatch is set for the files changed in the diff, along with the
+	// statements of the blocks that intersect with the changed lines.
+	inPatch      bool
+	patchTotal   int64
+	patchCovered int64
+
+	// unresolved is set for the files of other modules whose source code
+	// could not be found.
+	unresolved bool
+
+	// generated is set for the files with "Code generated ... DO NOT EDIT."
+	// comment.
+	generated bool
+
+	// problem is set when the coverage data doesn't match the source code.
+	problem ProblemKind
 }
 
 func (f *coverProfile) FilterValue() string { return f.profile.FileName }
 
+// moduleHeader starts the files of a module when the list shows the files of
+// several modules.
+type moduleHeader struct {
+	// path is empty for the files that don't belong to the modules of the
+	// code root.
+	path    string
+	total   int64
+	covered int64
+}
+
+func (h *moduleHeader) FilterValue() string { return h.path }
+
+// groupByModule inserts a header before the files of every module. The files
+// are already sorted by module.
+func groupByModule(items []list.Item, modules []Module) []list.Item {
+	grouped := make([]list.Item, 0, len(items)+len(modules)+1)
+
+	var header *moduleHeader
+
+	for _, item := range items {
+		f := item.(*coverProfile)
+
+		path := ""
+		if mod := ModuleOf(modules, f.profile.FileName); mod != nil {
+			path = mod.Path
+		}
+
+		if header == nil || header.path != path {
+			header = &moduleHeader{path: path}
+			grouped = append(grouped, header)
+		}
+
+		total, covered := CountStatements(f.profile)
+		header.total += total
+		header.covered += covered
+
+		grouped = append(grouped, f)
+	}
+
+	return grouped
+}
+
 type coverProfileDelegate struct{}
 
 func (d coverProfileDelegate) Height() int                             { return 1 }
 func (d coverProfileDelegate) Spacing() int                            { return 0 }
 func (d coverProfileDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d coverProfileDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	profile, ok := listItem.(*coverProfile)
-	if !ok {
+	// the tree is flattened while filtering, the full names are shown
+	flat := m.FilterState() != list.Unfiltered
+
+	var line string
+
+	switch item := listItem.(type) {
+	case *coverProfile:
+		line = d.renderBaseLine(item, item.profile.FileName)
+
+		// the files are shown below the headers of their modules
+		if _, grouped := m.Items()[0].(*moduleHeader); grouped {
+			line = indent(1) + line
+		}
+	case *treeFile:
+		if flat {
+			line = d.renderBaseLine(item.coverProfile, item.profile.FileName)
+		} else {
+			line = indent(item.depth) + "  " + d.renderBaseLine(item.coverProfile, path.Base(item.profile.FileName))
+		}
+	case *dirNode:
+		line = d.renderDirLine(item, flat)
+	case *moduleHeader:
+		line = d.renderModuleLine(item)
+	default:
 		return
 	}
 
-	line := d.renderBaseLine(profile)
-
 	if index == m.Index() {
 		line = selectedItemStyle.Foreground(lipgloss.Color(styles.CurrentTheme.PrimaryColor)).Render("> " + line)
 	} else {
@@ -50,9 +131,67 @@ func (d coverProfileDelegate) Render(w io.Writer, m list.Model, index int, listI
 	fmt.Fprint(w, line)
 }
 
-func (d coverProfileDelegate) renderBaseLine(p *coverProfile) string {
+func (d coverProfileDelegate) renderBaseLine(p *coverProfile, name string) string {
+	inactiveColor := lipgloss.Color(styles.CurrentTheme.InactiveColor)
+	text := fmt.Sprintf("%.2f%%", p.percentage)
+
+	if p.inPatch {
+		text += fmt.Sprintf(" (patch: %s)", formatPatchCoverage(p.patchCovered, p.patchTotal))
+	}
+
+	if p.problem != "" {
+		text += " (" + p.problem.badge() + ")"
+	}
+
+	if p.generated {
+		text += " (generated)"
+	}
+
+	percentage := percentageStyle.Foreground(inactiveColor).Render(text)
+
+	return fmt.Sprintf("%s %s", name, percentage)
+}
+
+func (d coverProfileDelegate) renderDirLine(dir *dirNode, flat bool) string {
+	inactiveColor := lipgloss.Color(styles.CurrentTheme.InactiveColor)
+	text := fmt.Sprintf("%.2f%%", dir.percentage())
+	percentage := percentageStyle.Foreground(inactiveColor).Render(text)
+
+	if flat {
+		return fmt.Sprintf("%s/ %s", dir.path, percentage)
+	}
+
+	marker := "▸ "
+	if dir.expanded {
+		marker = "▾ "
+	}
+
+	return fmt.Sprintf("%s%s%s/ %s", indent(dir.depth), marker, dir.name, percentage)
+}
+
+func (d coverProfileDelegate) renderModuleLine(h *moduleHeader) string {
 	inactiveColor := lipgloss.Color(styles.CurrentTheme.InactiveColor)
-	percentage := percentageStyle.Foreground(inactiveColor).Render(fmt.Sprintf("%.2f%%", p.percentage))
+	text := fmt.Sprintf("%.2f%%", Percent(h.covered, h.total))
+	percentage := percentageStyle.Foreground(inactiveColor).Render(text)
+
+	name := "module " + h.path
+	if h.path == "" {
+		name = "other modules"
+	}
+
+	return fmt.Sprintf("%s %s", name, percentage)
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// formatPatchCoverage renders the patch coverage percentage, or "n/a" when
+// there are no changed statements.
+func formatPatchCoverage(covered, total int64) string {
+	if total == 0 {
+		return "n/a"
+	}
 
-	return fmt.Sprintf("%s %s", p.profile.FileName, percentage)
+	return fmt.Sprintf("%.2f%%", Percent(covered, total))
 }
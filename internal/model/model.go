@@ -3,16 +3,13 @@
 package model
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path"
-	"regexp"
-	"sort"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -22,12 +19,11 @@ import (
 	"golang.org/x/tools/cover"
 )
 
-var modulePattern = regexp.MustCompile(`module\s+(.+)`)
-
 type viewName string
 
 const (
 	activeViewList  viewName = "list"
+	activeViewFuncs viewName = "funcs"
 	activeViewCode  viewName = "code"
 	activeViewError viewName = "error"
 )
@@ -46,38 +42,100 @@ func New(opts ...Option) *Model {
 		activeView: activeViewList,
 		helpState:  helpStateShort,
 		codeRoot:   ".",
-		list:       list.New([]list.Item{}, coverProfileDelegate{}, 0, 0),
+		keys:       defaultKeyMap(),
+		list:       newList(coverProfileDelegate{}, "Available files:"),
+		funcList:   newList(funcDelegate{}, "Functions:"),
+		codeParent: activeViewList,
+
+		expandedDirs: map[string]bool{},
+		packageDirs:  &packageDirs{},
 	}
 
-	m.list.Title = "Available files:"
-	m.list.SetShowStatusBar(true)
-	m.list.SetFilteringEnabled(true)
-	m.list.Styles.Title = titleStyle
-	m.list.FilterInput.PromptStyle = m.list.FilterInput.PromptStyle.Copy().Margin(1, 0, 0, 0)
-	m.list.Styles.PaginationStyle = paginationStyle
-	m.list.Styles.HelpStyle = helpStyle
-	m.list.Styles.StatusBar = statusBarStyle.Foreground(lipgloss.Color(styles.CurrentTheme.InactiveColor))
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keys.ToggleGenerated, m.keys.ToggleTree, m.keys.ExpandAll, m.keys.CollapseAll, m.keys.ToggleFuncs,
+		}
+	}
+	m.funcList.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{m.keys.ToggleFuncs, m.keys.ToggleSort}
+	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
+	m.funcsByCoverage = m.sortByCoverage
+
+	m.applyKeyMap()
+
 	return m
 }
 
+func newList(delegate list.ItemDelegate, title string) list.Model {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+
+	l.Title = title
+	l.SetShowStatusBar(true)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = titleStyle
+	l.FilterInput.PromptStyle = l.FilterInput.PromptStyle.Copy().Margin(1, 0, 0, 0)
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+	l.Styles.StatusBar = statusBarStyle.Foreground(lipgloss.Color(styles.CurrentTheme.InactiveColor))
+
+	return l
+}
+
 // Model implements tea.Model.
 type Model struct {
-	list  list.Model
-	items []list.Item
+	list     list.Model
+	items    []list.Item
+	profiles []*cover.Profile
+
+	// funcList shows the functions of the listed files; funcs keeps them
+	// in the order of declaration.
+	funcList        list.Model
+	funcs           []list.Item
+	funcsByCoverage bool
 
 	code codeview.Model
+	keys keyMap
+
+	// codeParent is the view the open file returns to, and scrollToLine is
+	// the line the file is scrolled to once loaded.
+	codeParent   viewName
+	scrollToLine int
 
 	codeRoot            string
-	profileFilename     string
+	profileFilenames    []string
+	coverDirs           []string
 	sortByCoverage      bool
 	detectedPackageName string
+	modules             []Module
+	sourcePaths         map[string]string
+	unresolvedFiles     map[string]bool
+	unknownModules      map[string]bool
+	packageDirs         *packageDirs
+	problems            map[string]Problem
 	requestedFiles      map[string]bool
+	includePatterns     []string
+	excludePatterns     []string
+	generatedFiles      map[string]bool
+	showGenerated       bool
+	treeView            bool
+	tree                *dirNode
+	listFiltered        bool
+	expandedDirs        map[string]bool
+	subdir              string
 	filteredLinesByFile map[string][]int
+	inputErr            error
+	openFile            string
+
+	watchInterval     time.Duration
+	watchStarted      bool
+	watchedProfiles   string
+	watchedSourceFile string
+	watchedSource     string
 
 	activeView viewName
 	helpState  helpState
@@ -88,7 +146,16 @@ type Model struct {
 
 // Init implements tea.Model.
 func (m *Model) Init() tea.Cmd {
-	return m.loadProfiles(m.codeRoot, m.profileFilename)
+	if m.inputErr != nil {
+		err := m.inputErr
+		return func() tea.Msg { return err }
+	}
+
+	if m.watchInterval > 0 {
+		return tea.Batch(m.loadProfiles(m.codeRoot, m.profileFilenames), m.watch())
+	}
+
+	return m.loadProfiles(m.codeRoot, m.profileFilenames)
 }
 
 // Update implements tea.Model.
@@ -97,14 +164,26 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		return m.updateWindowSize(msg.Width, msg.Height)
 
-	case []*cover.Profile:
+	case *loadedProfiles:
 		return m.onProfilesLoaded(msg)
 
 	case fileContents:
 		return m.onFileContentLoaded(msg)
 
+	case loadedFuncs:
+		return m.onFuncsLoaded(msg)
+
+	case watchMsg:
+		return m.onWatch(msg)
+
+	case reloadedProfiles:
+		return m.onProfilesReloaded(msg)
+
+	case reloadedFileContents:
+		return m.onFileContentReloaded(msg)
+
 	case tea.KeyMsg:
-		if m, cmd := m.onKeyPressed(msg.String()); m != nil {
+		if m, cmd := m.onKeyPressed(msg); m != nil {
 			return m, cmd
 		}
 
@@ -117,6 +196,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.activeView {
 	case activeViewList:
 		m.list, cmd = m.list.Update(msg)
+		cmd = tea.Batch(cmd, m.syncTreeFilter())
+	case activeViewFuncs:
+		m.funcList, cmd = m.funcList.Update(msg)
 	case activeViewCode:
 		m.code, cmd = m.code.Update(msg)
 	case activeViewError:
@@ -144,6 +226,10 @@ func (m *Model) View() string {
 		return m.list.View()
 	}
 
+	if m.isFuncsView() {
+		return m.funcList.View()
+	}
+
 	return "Unknown view"
 }
 
@@ -163,6 +249,8 @@ func (m *Model) updateWindowSize(width, height int) (tea.Model, tea.Cmd) {
 	if !m.ready {
 		m.code = codeview.New(width, height)
 		m.ready = true
+
+		m.applyKeyMap()
 	}
 
 	m.code.SetWidth(width)
@@ -171,6 +259,9 @@ func (m *Model) updateWindowSize(width, height int) (tea.Model, tea.Cmd) {
 	m.list.SetWidth(width)
 	m.list.SetHeight(height - 1)
 
+	m.funcList.SetWidth(width)
+	m.funcList.SetHeight(height - 1)
+
 	return m, nil
 }
 
@@ -181,56 +272,98 @@ func (m *Model) onError(err error) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *Model) onProfilesLoaded(profiles []*cover.Profile) (tea.Model, tea.Cmd) {
-	if len(profiles) == 0 {
+func (m *Model) onProfilesLoaded(loaded *loadedProfiles) (tea.Model, tea.Cmd) {
+	if len(loaded.profiles) == 0 && m.filteredLinesByFile == nil {
 		return m.onError(errNoProfiles{})
 	}
 
-	if m.sortByCoverage {
-		sort.Slice(profiles, func(i, j int) bool {
-			return percentCovered(profiles[i]) < percentCovered(profiles[j])
-		})
+	var title string
+
+	m.applyProfiles(loaded)
+	m.items, title = m.buildItems(m.profiles)
+	if title != "" {
+		m.list.Title = title
+	}
+
+	cmd := m.list.SetItems(m.listItems())
+
+	// the first file is selected rather than the header of its module
+	if _, ok := m.list.SelectedItem().(*moduleHeader); ok {
+		m.list.Select(1)
 	}
 
-	m.items = make([]list.Item, len(profiles))
+	return m, cmd
+}
+
+// buildItems creates the list items from the profiles. If the diff is
+// provided, it also returns the list title with the patch coverage.
+func (m *Model) buildItems(profiles []*cover.Profile) ([]list.Item, string) {
+	items := make([]list.Item, 0, len(profiles))
+
+	var patchTotal, patchCovered int64
 
-	for i, p := range profiles {
-		// package name should already be set
-		p.FileName = strings.TrimPrefix(p.FileName, m.detectedPackageName+"/")
-		m.items[i] = &coverProfile{
+	for _, p := range m.visibleProfiles(profiles) {
+		item := &coverProfile{
 			profile:    p,
-			percentage: percentCovered(p),
+			percentage: PercentCovered(p),
+			unresolved: m.unresolvedFiles[p.FileName],
+			generated:  m.generatedFiles[p.FileName],
 		}
+
+		if problem, ok := m.problems[p.FileName]; ok {
+			item.problem = problem.Kind
+		}
+
+		if changedLines, ok := m.filteredLinesByFile[p.FileName]; ok {
+			item.inPatch = true
+			item.patchTotal, item.patchCovered = PatchStatements(p, changedLines)
+			patchTotal += item.patchTotal
+			patchCovered += item.patchCovered
+		}
+
+		items = append(items, item)
+	}
+
+	if m.filteredLinesByFile != nil {
+		return items, "Patch coverage: " + formatPatchCoverage(patchCovered, patchTotal)
 	}
 
-	return m, m.list.SetItems(m.items)
+	if len(m.modules) > 1 {
+		return items, fmt.Sprintf("Files in %d modules:", len(m.modules))
+	}
+
+	return items, ""
 }
 
 func (m *Model) onFileContentLoaded(content []string) (tea.Model, tea.Cmd) {
 	m.code.SetContent(content)
 	m.activeView = activeViewCode
 
+	if m.scrollToLine > 0 {
+		m.code.ScrollToLine(m.scrollToLine)
+	}
+
 	return m, nil
 }
 
-func (m *Model) onKeyPressed(key string) (tea.Model, tea.Cmd) {
+func (m *Model) onKeyPressed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// allow error model to process the keys
 	if m.isErrorView() {
 		return nil, nil
 	}
 
 	// don't match any of the keys below if we're actively filtering.
-	if m.list.FilterState() == list.Filtering {
+	if m.list.FilterState() == list.Filtering || m.isFuncsView() && m.funcList.FilterState() == list.Filtering {
 		return nil, nil
 	}
 
-	switch key {
-	case "ctrl+c", "q":
+	switch {
+	case key.Matches(msg, m.keys.Quit):
 		return m, tea.Quit
 
-	case "esc":
+	case key.Matches(msg, m.keys.Back):
 		if m.isCodeView() {
-			m.activeView = activeViewList
+			m.activeView = m.codeParent
 			return m, nil
 		}
 
@@ -241,29 +374,124 @@ func (m *Model) onKeyPressed(key string) (tea.Model, tea.Cmd) {
 			}
 		}
 
-	case "enter":
-		item, ok := m.list.SelectedItem().(*coverProfile)
-		if ok {
-			m.code.SetTitle(item.profile.FileName)
+		// the functions view returns to the files once the filter is cleared
+		if m.isFuncsView() && m.funcList.FilterState() == list.Unfiltered {
+			m.activeView = activeViewList
+			return m, nil
+		}
+
+	case key.Matches(msg, m.keys.Open):
+		if m.isFuncsView() {
+			if item, ok := m.funcList.SelectedItem().(*funcItem); ok {
+				return m, m.openFunc(item)
+			}
 
-			filteredInFile := m.filteredLinesByFile[item.profile.FileName]
-			m.code.SetFilteredLines(filteredInFile)
+			return m, nil
+		}
+
+		if d, ok := m.list.SelectedItem().(*dirNode); ok && m.isListView() {
+			return m, m.toggleDir(d)
+		}
 
-			adjustedFileName := path.Join(m.codeRoot, item.profile.FileName)
+		item, ok := selectedProfile(m.list.SelectedItem())
+		if ok {
+			m.codeParent = activeViewList
+			m.scrollToLine = 0
 
-			return m, loadFile(adjustedFileName, item.profile)
+			return m, m.openProfile(item)
 		}
 
 		return m, nil
 
-	case "?":
+	case key.Matches(msg, m.keys.Help):
 		m.toggleHelp()
 		return m, nil
+
+	case key.Matches(msg, m.keys.ToggleGenerated):
+		if m.isListView() {
+			return m, m.toggleGenerated()
+		}
+
+	case key.Matches(msg, m.keys.ToggleTree):
+		if m.isListView() {
+			return m, m.toggleTree()
+		}
+
+	case key.Matches(msg, m.keys.ExpandAll):
+		if m.isListView() && m.treeView {
+			return m, m.expandAll(true)
+		}
+
+	case key.Matches(msg, m.keys.CollapseAll):
+		if m.isListView() && m.treeView {
+			return m, m.expandAll(false)
+		}
+
+	case key.Matches(msg, m.keys.ToggleFuncs):
+		if m.isListView() || m.isFuncsView() {
+			return m, m.toggleFuncs()
+		}
+
+	case key.Matches(msg, m.keys.ToggleSort):
+		if m.isFuncsView() {
+			return m, m.toggleFuncsSort()
+		}
 	}
 
 	return nil, nil
 }
 
+// openProfile loads the source code of the file into the code view.
+func (m *Model) openProfile(item *coverProfile) tea.Cmd {
+	if item.unresolved {
+		return m.list.NewStatusMessage("Source code not found")
+	}
+
+	m.code.SetTitle(item.profile.FileName)
+	m.openFile = item.profile.FileName
+
+	filteredInFile := m.filteredLinesByFile[item.profile.FileName]
+	m.code.SetFilteredLines(filteredInFile)
+
+	return loadFile(m.sourceFile(item.profile.FileName), item.profile)
+}
+
+// toggleGenerated shows or hides the generated files in the list.
+func (m *Model) toggleGenerated() tea.Cmd {
+	if len(m.generatedFiles) == 0 {
+		return m.list.NewStatusMessage("No generated files")
+	}
+
+	m.showGenerated = !m.showGenerated
+	m.items, _ = m.buildItems(m.profiles)
+	cmd := m.list.SetItems(m.listItems())
+
+	status := "Hiding generated"
+	if m.showGenerated {
+		status = "Showing generated"
+	}
+
+	return tea.Batch(cmd, m.list.NewStatusMessage(status))
+}
+
+// visibleProfiles returns the profiles without the generated files, unless
+// they should be shown.
+func (m *Model) visibleProfiles(profiles []*cover.Profile) []*cover.Profile {
+	if m.showGenerated || len(m.generatedFiles) == 0 {
+		return profiles
+	}
+
+	visible := make([]*cover.Profile, 0, len(profiles))
+
+	for _, p := range profiles {
+		if !m.generatedFiles[p.FileName] {
+			visible = append(visible, p)
+		}
+	}
+
+	return visible
+}
+
 func (m *Model) toggleHelp() {
 	// manage help state globally: allow to extend or hide completely
 	switch m.helpState {
@@ -272,6 +500,8 @@ func (m *Model) toggleHelp() {
 
 		m.list.Help.ShowAll = false
 		m.list.SetShowHelp(true)
+		m.funcList.Help.ShowAll = false
+		m.funcList.SetShowHelp(true)
 
 		m.code.SetShowFullHelp(false)
 		m.code.SetShowHelp(true)
@@ -280,6 +510,8 @@ func (m *Model) toggleHelp() {
 
 		m.list.Help.ShowAll = true
 		m.list.SetShowHelp(true)
+		m.funcList.Help.ShowAll = true
+		m.funcList.SetShowHelp(true)
 
 		m.code.SetShowFullHelp(true)
 		m.code.SetShowHelp(true)
@@ -288,89 +520,242 @@ func (m *Model) toggleHelp() {
 
 		m.list.Help.ShowAll = false
 		m.list.SetShowHelp(false)
+		m.funcList.Help.ShowAll = false
+		m.funcList.SetShowHelp(false)
 
 		m.code.SetShowFullHelp(false)
 		m.code.SetShowHelp(false)
 	}
 }
 
-func (m *Model) loadProfiles(codeRoot, profileFilename string) tea.Cmd {
+func (m *Model) loadProfiles(codeRoot string, profileFilenames []string) tea.Cmd {
 	return func() tea.Msg {
-		gomodFile := path.Join(codeRoot, "go.mod")
-		profilesFile := path.Join(codeRoot, profileFilename)
-
-		pkg, err := determinePackageName(gomodFile)
+		loaded, err := m.readProfiles(codeRoot, profileFilenames, false)
 		if err != nil {
-			return fmt.Errorf("failed to determine package name: %w", err)
+			return err
 		}
 
-		profiles, err := cover.ParseProfiles(profilesFile)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return errNoCoverageFile{err}
+		return loaded
+	}
+}
+
+// LoadProfiles reads the coverage profiles synchronously, applying the same
+// filtering and sorting as the interactive list does. It allows to reuse the
+// model configuration in the non-interactive outputs. When the diff doesn't
+// change any file of the profiles, no profiles are returned without an error.
+func (m *Model) LoadProfiles() ([]*cover.Profile, error) {
+	if m.inputErr != nil {
+		return nil, m.inputErr
+	}
+
+	loaded, err := m.readProfiles(m.codeRoot, m.profileFilenames, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.applyProfiles(loaded)
+	profiles := m.visibleProfiles(m.profiles)
+
+	if len(profiles) == 0 && m.filteredLinesByFile == nil {
+		return nil, errNoProfiles{errors.New("no coverage data")}
+	}
+
+	return profiles, nil
+}
+
+// CodeRoot returns the directory of the source code files.
+func (m *Model) CodeRoot() string {
+	return m.codeRoot
+}
+
+// ModuleName returns the name of the Go module detected while loading the
+// profiles. In a workspace without a module in the code root, it is empty.
+func (m *Model) ModuleName() string {
+	return m.detectedPackageName
+}
+
+// Modules returns all the Go modules detected while loading the profiles.
+// File names of the profiles are relative to the code root, and include the
+// directories of the modules.
+func (m *Model) Modules() []Module {
+	return m.modules
+}
+
+// loadedProfiles are the profiles read from the disk, along with what was
+// found out about their files. They are read outside of the update loop, so
+// the model only changes once they are applied.
+type loadedProfiles struct {
+	profiles        []*cover.Profile
+	codeRoot        string
+	modules         []Module
+	sourcePaths     map[string]string
+	unresolvedFiles map[string]bool
+	unknownModules  map[string]bool
+	generatedFiles  map[string]bool
+	problems        map[string]Problem
+}
+
+// readProfiles reads the profiles and checks their files. Unless all the
+// entries are requested, the files that are not requested, excluded or
+// outside of the subdirectory are skipped. It runs in the commands, and must
+// not change the model.
+func (m *Model) readProfiles(codeRoot string, profileFilenames []string, all bool) (*loadedProfiles, error) {
+	modules, err := findModules(codeRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine package name: %w", err)
+	}
+
+	profiles, err := parseProfiles(codeRoot, profileFilenames, m.coverDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	finalProfiles := make([]*cover.Profile, 0, len(profiles))
+	allFilesRequested := all || m.requestedFiles == nil
+	outsideFiles := []string{}
+
+	for _, p := range profiles {
+		fileName, inModule := moduleFile(modules, p.FileName)
+		p.FileName = fileName
+
+		if !allFilesRequested {
+			if _, ok := m.requestedFiles[p.FileName]; !ok {
+				log.Println("skipping", p.FileName)
+				continue
 			}
+		}
 
-			return errInvalidCoverageFile{err}
+		if !all && (len(m.includePatterns) > 0 && !matchesAny(m.includePatterns, p.FileName) ||
+			matchesAny(m.excludePatterns, p.FileName)) {
+			log.Println("excluding", p.FileName)
+			continue
 		}
 
-		finalProfiles := make([]*cover.Profile, 0, len(profiles))
-		allFilesRequested := len(m.requestedFiles) == 0
+		if !inModule && !fileExists(resolvePath(codeRoot, p.FileName)) {
+			outsideFiles = append(outsideFiles, p.FileName)
+		}
 
-		for _, p := range profiles {
-			p.FileName = strings.TrimPrefix(p.FileName, pkg+"/")
+		finalProfiles = append(finalProfiles, p)
+	}
 
-			if !allFilesRequested {
-				if _, ok := m.requestedFiles[p.FileName]; !ok {
-					log.Println("skipping", p.FileName)
-					continue
+	loaded := &loadedProfiles{
+		codeRoot:        codeRoot,
+		modules:         modules,
+		sourcePaths:     map[string]string{},
+		unresolvedFiles: map[string]bool{},
+		unknownModules:  map[string]bool{},
+		generatedFiles:  map[string]bool{},
+		problems:        map[string]Problem{},
+	}
+
+	if len(outsideFiles) > 0 {
+		resolver := newSourceResolver(codeRoot, modules, m.packageDirs)
+		loaded.sourcePaths = resolver.resolve(outsideFiles)
+
+		for _, fileName := range outsideFiles {
+			if _, ok := loaded.sourcePaths[fileName]; !ok {
+				log.Println("source not found:", fileName)
+				loaded.unresolvedFiles[fileName] = true
+
+				if !resolver.knowsModule(fileName) {
+					loaded.unknownModules[fileName] = true
 				}
 			}
+		}
+	}
 
-			finalProfiles = append(finalProfiles, p)
+	if !all && allFilesRequested && m.subdir != "" {
+		finalProfiles = filterSubdirectory(finalProfiles, m.subdir)
+	}
+
+	for _, p := range finalProfiles {
+		if !loaded.unresolvedFiles[p.FileName] && isGeneratedFile(loaded.sourceFile(p.FileName)) {
+			loaded.generatedFiles[p.FileName] = true
 		}
+	}
 
-		return finalProfiles
+	modTime := profilesModTime(codeRoot, profileFilenames, m.coverDirs)
+
+	for _, p := range finalProfiles {
+		if problem, ok := loaded.diagnose(p, modTime); ok {
+			log.Println("problem:", p.FileName, problem.Kind)
+			loaded.problems[p.FileName] = problem
+		}
 	}
+
+	sortByModule(modules, finalProfiles, m.sortByCoverage)
+
+	loaded.profiles = finalProfiles
+
+	return loaded, nil
 }
 
-func determinePackageName(gomodFile string) (string, error) {
-	bs, err := os.ReadFile(gomodFile) // nolint: gosec
-	if err != nil {
-		return "", errGoModNotFound{err}
+// applyProfiles makes the model use the loaded profiles.
+func (m *Model) applyProfiles(loaded *loadedProfiles) {
+	m.profiles = loaded.profiles
+	m.modules = loaded.modules
+	m.sourcePaths = loaded.sourcePaths
+	m.unresolvedFiles = loaded.unresolvedFiles
+	m.unknownModules = loaded.unknownModules
+	m.generatedFiles = loaded.generatedFiles
+	m.problems = loaded.problems
+	m.detectedPackageName = ""
+
+	if len(m.modules) > 0 && m.modules[0].Dir == "." {
+		m.detectedPackageName = m.modules[0].Path
 	}
+}
+
+// sourceFile returns the location of the file on disk. Files of other
+// modules are resolved when the profiles are loaded.
+func (m *Model) sourceFile(fileName string) string {
+	return sourceFile(m.codeRoot, m.sourcePaths, fileName)
+}
 
-	content := strings.ReplaceAll(string(bs), "\r\n", "\n")
-	matches := modulePattern.FindStringSubmatch(content)
+func (l *loadedProfiles) sourceFile(fileName string) string {
+	return sourceFile(l.codeRoot, l.sourcePaths, fileName)
+}
 
-	if len(matches) == 0 {
-		return "", errInvalidGoMod{}
+func sourceFile(codeRoot string, sourcePaths map[string]string, fileName string) string {
+	if filename, ok := sourcePaths[fileName]; ok {
+		return filename
 	}
 
-	return matches[1], nil
+	return resolvePath(codeRoot, fileName)
 }
 
-type fileContents []string
+// SourceFiles returns the locations of the files that don't belong to the
+// modules of the code root, by their import paths.
+func (m *Model) SourceFiles() map[string]string {
+	return m.sourcePaths
+}
 
-// nolint: gosec
-func loadFile(filename string, profile *cover.Profile) tea.Cmd {
-	return func() tea.Msg {
-		f, err := os.Open(filename)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return errSourceFileNotFound{err}
-			}
+// filterSubdirectory returns the profiles of the files in the directory, or
+// all the profiles if there are no such files.
+func filterSubdirectory(profiles []*cover.Profile, dir string) []*cover.Profile {
+	filtered := make([]*cover.Profile, 0, len(profiles))
 
-			return errCantOpenSourceFile{fmt.Errorf("could not open file %s: %w", filename, err)}
+	for _, p := range profiles {
+		if strings.HasPrefix(p.FileName, dir+"/") {
+			filtered = append(filtered, p)
 		}
+	}
 
-		defer func() { _ = f.Close() }()
+	if len(filtered) == 0 {
+		log.Println("no files in", dir)
+		return profiles
+	}
 
-		scanner := bufio.NewScanner(f)
+	return filtered
+}
 
-		var lines []string
+type fileContents []string
 
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+func loadFile(filename string, profile *cover.Profile) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := ReadLines(filename)
+		if err != nil {
+			return err
 		}
 
 		highlightedText, err := colorize(lines, profile)
@@ -382,76 +767,53 @@ func loadFile(filename string, profile *cover.Profile) tea.Cmd {
 	}
 }
 
-func colorize(lines []string, profile *cover.Profile) (contents fileContents, err error) {
-	defer func() {
-		if rr := recover(); rr != nil {
-			err = fmt.Errorf("%s", rr)
-		}
-	}()
-
-	buf := make(fileContents, 0, len(lines))
-
-	for lineIdx, blockIdx := 0, 0; lineIdx < len(lines); lineIdx++ {
-		line, block := lines[lineIdx], profile.Blocks[blockIdx]
-
-		coverageStyle := styles.CurrentTheme.UncoveredLine
-		if block.Count > 0 {
-			coverageStyle = styles.CurrentTheme.CoveredLine
-		}
-
-		adjustedStartLine, adjustedEndLine := block.StartLine-1, block.EndLine-1
+func colorize(lines []string, profile *cover.Profile) (fileContents, error) {
+	annotatedLines, err := AnnotateLines(lines, profile)
+	if err != nil {
+		return nil, err
+	}
 
-		// before the first block - not covered
-		if lineIdx < adjustedStartLine {
-			buf = append(buf, styles.CurrentTheme.NeutralLine.Render(line))
-			continue
-		}
+	buf := make(fileContents, 0, len(annotatedLines))
 
-		// first line - highlight from the start col
-		if lineIdx == adjustedStartLine {
-			uncoveredPart := styles.CurrentTheme.NeutralLine.Render(line[:block.StartCol-1])
-			coveredPart := coverageStyle.Render(line[block.StartCol-1:])
-			buf = append(buf, fmt.Sprintf("%s%s", uncoveredPart, coveredPart))
+	for _, parts := range annotatedLines {
+		var sb strings.Builder
 
-			continue
+		for _, part := range parts {
+			sb.WriteString(lineCoverageStyle(part.Coverage).Render(part.Text))
 		}
 
-		// inside any block - can be anything
-		if lineIdx >= adjustedStartLine && lineIdx <= adjustedEndLine {
-			// TODO: support end column as well
-			if block.NumStmt > 0 {
-				buf = append(buf, coverageStyle.Render(line))
-			} else {
-				buf = append(buf, styles.CurrentTheme.NeutralLine.Render(line))
-			}
-
-			continue
-		}
-
-		// after a block - might be the last block or just bump the block
-		if lineIdx > adjustedEndLine {
-			// when there are more blocks, bump the block
-			if blockIdx < len(profile.Blocks)-1 {
-				blockIdx++
-				lineIdx--
-			} else {
-				buf = append(buf, styles.CurrentTheme.NeutralLine.Render(line))
-			}
-		}
+		buf = append(buf, sb.String())
 	}
 
 	return buf, nil
 }
 
-// percentCovered returns, as a percentage, the fraction of the statements in
+func lineCoverageStyle(c LineCoverage) lipgloss.Style {
+	switch c {
+	case LineCovered:
+		return styles.CurrentTheme.CoveredLine
+	case LineUncovered:
+		return styles.CurrentTheme.UncoveredLine
+	default:
+		return styles.CurrentTheme.NeutralLine
+	}
+}
+
+// PercentCovered returns, as a percentage, the fraction of the statements in
 // the profile covered by the test run.
 // In effect, it reports the coverage of a given source file.
 //
 // Taken from golang/tools repo.
 // https://github.com/golang/tools/blob/master/cmd/cover/html.go
-func percentCovered(p *cover.Profile) float64 {
-	var total, covered int64
+func PercentCovered(p *cover.Profile) float64 {
+	total, covered := CountStatements(p)
+
+	return Percent(covered, total)
+}
 
+// CountStatements returns the total number of statements in the profile, and
+// the number of statements covered by the test run.
+func CountStatements(p *cover.Profile) (total, covered int64) {
 	for _, b := range p.Blocks {
 		total += int64(b.NumStmt)
 
@@ -460,6 +822,12 @@ func percentCovered(p *cover.Profile) float64 {
 		}
 	}
 
+	return total, covered
+}
+
+// Percent returns the covered part of the total as a percentage. Empty totals
+// are reported as not covered.
+func Percent(covered, total int64) float64 {
 	if total == 0 {
 		return 0
 	}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/cover"
+)
+
+// Module is a Go module that the coverage data belongs to.
+type Module struct {
+	// Path is the module path declared in go.mod.
+	Path string
+
+	// Dir is the module directory relative to the code root, "." for the
+	// module in the code root.
+	Dir string
+
+	// GoVersion is the version declared in the go directive of go.mod.
+	GoVersion string
+}
+
+// findModules returns the modules of the code root. In a workspace, the
+// modules are listed in go.work. Otherwise, the code root must be a module,
+// and every nested module found in its subdirectories is included.
+// Modules are sorted by their directories, so the root module comes first.
+func findModules(codeRoot string) ([]Module, error) {
+	dirs, err := workspaceDirs(path.Join(codeRoot, "go.work"))
+	if err != nil {
+		return nil, err
+	}
+
+	workspace := dirs != nil
+
+	if !workspace {
+		dirs, err = nestedModuleDirs(codeRoot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	modules := make([]Module, 0, len(dirs))
+
+	for _, dir := range dirs {
+		mod, err := readGoMod(path.Join(codeRoot, dir, "go.mod"))
+		if err != nil {
+			// broken nested modules should not prevent using the root one
+			if !workspace && dir != "." {
+				log.Println("skipping module in", dir, err)
+				continue
+			}
+
+			return nil, err
+		}
+
+		modules = append(modules, Module{Path: mod.modulePath, Dir: path.Clean(dir), GoVersion: mod.goVersion})
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return moduleDirLess(modules[i].Dir, modules[j].Dir)
+	})
+
+	return modules, nil
+}
+
+// workspaceDirs returns the module directories used in the go.work file, or
+// nil if there is no such file.
+func workspaceDirs(goworkFile string) ([]string, error) {
+	bs, err := os.ReadFile(goworkFile) // nolint: gosec
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errInvalidGoWork{err}
+	}
+
+	f, err := modfile.ParseWork(goworkFile, bs, nil)
+	if err != nil {
+		return nil, errInvalidGoWork{err}
+	}
+
+	dirs := make([]string, 0, len(f.Use))
+	for _, use := range f.Use {
+		dirs = append(dirs, use.Path)
+	}
+
+	if len(dirs) == 0 {
+		return nil, errInvalidGoWork{errors.New("go.work file does not use any modules")}
+	}
+
+	return dirs, nil
+}
+
+// nestedModuleDirs returns the code root and its subdirectories that have a
+// go.mod file. Hidden directories, vendor and testdata are skipped, like the
+// go command does.
+func nestedModuleDirs(codeRoot string) ([]string, error) {
+	dirs := []string{"."}
+
+	err := filepath.WalkDir(codeRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if p != codeRoot && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "vendor" || name == "testdata") {
+			return filepath.SkipDir
+		}
+
+		if p == codeRoot {
+			return nil
+		}
+
+		if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+			rel, err := filepath.Rel(codeRoot, p)
+			if err != nil {
+				return err
+			}
+
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	return dirs, err
+}
+
+// moduleFile returns the name of the file relative to the code root, using
+// its import path, and whether the file belongs to any of the modules. Nested
+// modules take precedence over their parents. Files outside of the modules
+// are returned as is.
+func moduleFile(modules []Module, fileName string) (string, bool) {
+	var found *Module
+
+	for i, mod := range modules {
+		if !strings.HasPrefix(fileName, mod.Path+"/") {
+			continue
+		}
+
+		if found == nil || len(mod.Path) > len(found.Path) {
+			found = &modules[i]
+		}
+	}
+
+	if found == nil {
+		return fileName, false
+	}
+
+	return path.Join(found.Dir, strings.TrimPrefix(fileName, found.Path+"/")), true
+}
+
+// ModuleOf returns the module the file belongs to, using the file name
+// relative to the code root. Nested modules take precedence over their
+// parents.
+func ModuleOf(modules []Module, fileName string) *Module {
+	var (
+		found *Module
+		depth = -1
+	)
+
+	for i, mod := range modules {
+		d := len(mod.Dir)
+
+		switch {
+		case mod.Dir == ".":
+			d = 0
+		case !strings.HasPrefix(fileName, mod.Dir+"/"):
+			continue
+		}
+
+		if d > depth {
+			found, depth = &modules[i], d
+		}
+	}
+
+	return found
+}
+
+// sortByModule groups the profiles by module. Within each module, the
+// profiles are sorted by coverage if requested, otherwise the order is kept.
+func sortByModule(modules []Module, profiles []*cover.Profile, byCoverage bool) {
+	sort.SliceStable(profiles, func(i, j int) bool {
+		mi, mj := ModuleOf(modules, profiles[i].FileName), ModuleOf(modules, profiles[j].FileName)
+		if mi == nil || mj == nil {
+			return mi != nil && mj == nil
+		}
+
+		if mi.Dir != mj.Dir {
+			return moduleDirLess(mi.Dir, mj.Dir)
+		}
+
+		return byCoverage && PercentCovered(profiles[i]) < PercentCovered(profiles[j])
+	})
+}
+
+// moduleDirLess orders the module directories alphabetically, with the code
+// root first.
+func moduleDirLess(a, b string) bool {
+	if a == "." || b == "." {
+		return a == "." && b != "."
+	}
+
+	return a < b
+}
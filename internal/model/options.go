@@ -1,12 +1,37 @@
 package model
 
+import "time"
+
 // Option is a function that can be used to modify the model.
 type Option func(*Model)
 
 // WithProfileFilename sets the filename of the coverage report to be loaded.
 func WithProfileFilename(name string) Option {
+	return WithProfileFilenames(name)
+}
+
+// WithProfileFilenames sets the filenames or glob patterns of the coverage
+// reports to be loaded. Multiple reports are merged into one.
+func WithProfileFilenames(names ...string) Option {
+	return func(m *Model) {
+		m.profileFilenames = names
+	}
+}
+
+// WithCoverDirs sets the directories with binary coverage data written by
+// the binaries built with "go build -cover". The data is merged with the
+// coverage reports, if any.
+func WithCoverDirs(dirs ...string) Option {
+	return func(m *Model) {
+		m.coverDirs = dirs
+	}
+}
+
+// WithWatch enables reloading the coverage data and the open source file when
+// they change on disk. The files are checked every interval.
+func WithWatch(interval time.Duration) Option {
 	return func(m *Model) {
-		m.profileFilename = name
+		m.watchInterval = interval
 	}
 }
 
@@ -24,10 +49,24 @@ func WithCodeRoot(root string) Option {
 	}
 }
 
-// WithRequestedFiles sets the list of files to be displayed.
+// WithSubdirectory limits the displayed files to the given subdirectory of
+// the code root, unless specific files are requested. If there are no files in
+// the subdirectory, all the files are displayed.
+func WithSubdirectory(dir string) Option {
+	return func(m *Model) {
+		m.subdir = dir
+	}
+}
+
+// WithRequestedFiles sets the list of files to be displayed. All the files are
+// displayed when the list is nil, and none when it is empty.
 func WithRequestedFiles(files []string) Option {
 	return func(m *Model) {
-		m.requestedFiles = make(map[string]bool)
+		if files == nil {
+			return
+		}
+
+		m.requestedFiles = make(map[string]bool, len(files))
 
 		for _, v := range files {
 			m.requestedFiles[v] = true
@@ -35,15 +74,54 @@ func WithRequestedFiles(files []string) Option {
 	}
 }
 
+// WithIncludePatterns sets the patterns of the files to be loaded. When set,
+// other files are skipped. The patterns follow .gitignore rules.
+func WithIncludePatterns(patterns ...string) Option {
+	return func(m *Model) {
+		m.includePatterns = patterns
+	}
+}
+
+// WithExcludePatterns sets the patterns of the files to be skipped. The
+// patterns follow .gitignore rules.
+func WithExcludePatterns(patterns ...string) Option {
+	return func(m *Model) {
+		m.excludePatterns = patterns
+	}
+}
+
+// WithGeneratedFiles asks for the generated files to be shown. By default,
+// they are hidden.
+func WithGeneratedFiles(show bool) Option {
+	return func(m *Model) {
+		m.showGenerated = show
+	}
+}
+
+// WithInputError sets the error that occurred while reading the list of
+// files or the diff. The error is displayed instead of the coverage.
+func WithInputError(err error) Option {
+	return func(m *Model) {
+		if err != nil {
+			m.inputErr = errInvalidInput{err}
+		}
+	}
+}
+
 // WithFilteredLines sets a list of lines to display for every file. Other
-// lines will not appear.
+// lines will not appear. An empty map is a diff without changed Go files: no
+// files are shown, and it is not an error.
 func WithFilteredLines(files map[string][]int) Option {
 	return func(m *Model) {
+		if files == nil {
+			return
+		}
+
 		m.filteredLinesByFile = make(map[string][]int, len(files))
-		uniqueLines := map[int]interface{}{}
 
 		for file, lines := range files {
 			linesWithContext := make([]int, 0, len(lines))
+			uniqueLines := map[int]interface{}{}
 
 			for _, line := range lines {
 				if _, ok := uniqueLines[line]; !ok {
@@ -56,3 +134,22 @@ func WithFilteredLines(files map[string][]int) Option {
 		}
 	}
 }
+
+// WithKeyBindings replaces the keys of the actions listed in KeyActions. The
+// first key of every action is shown in the help. The bindings are not
+// checked here, see CheckKeyBindings.
+func WithKeyBindings(bindings map[string][]string) Option {
+	return func(m *Model) {
+		for action, keys := range bindings {
+			m.keys.bind(action, keys)
+		}
+	}
+}
+
+// WithTreeView shows the files grouped by their directories, with the
+// aggregated coverage of every directory.
+func WithTreeView(tree bool) Option {
+	return func(m *Model) {
+		m.treeView = tree
+	}
+}
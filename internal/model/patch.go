@@ -0,0 +1,86 @@
+package model
+
+import (
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// PatchStatements returns the number of statements in the blocks that
+// intersect with the changed lines, and the number of covered statements
+// among them. This is the coverage of the patch rather than of the file.
+func PatchStatements(p *cover.Profile, changedLines []int) (total, covered int64) {
+	lines := sortedLines(changedLines)
+
+	for _, b := range p.Blocks {
+		if b.NumStmt == 0 || !intersects(lines, b.StartLine, b.EndLine) {
+			continue
+		}
+
+		total += int64(b.NumStmt)
+
+		if b.Count > 0 {
+			covered += int64(b.NumStmt)
+		}
+	}
+
+	return total, covered
+}
+
+// UncoveredLines returns the changed lines that belong to statement blocks
+// not covered by the test run. Lines shared by covered and uncovered blocks,
+// such as "} else {", are considered covered.
+func UncoveredLines(p *cover.Profile, changedLines []int) []int {
+	lines := sortedLines(changedLines)
+	covered := make(map[int]bool, len(lines))
+	uncovered := make(map[int]bool, len(lines))
+
+	for _, b := range p.Blocks {
+		if b.NumStmt == 0 {
+			continue
+		}
+
+		target := uncovered
+		if b.Count > 0 {
+			target = covered
+		}
+
+		start := sort.SearchInts(lines, b.StartLine)
+		for i := start; i < len(lines) && lines[i] <= b.EndLine; i++ {
+			target[lines[i]] = true
+		}
+	}
+
+	result := make([]int, 0, len(uncovered))
+
+	for _, line := range lines {
+		if uncovered[line] && !covered[line] {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// sortedLines returns a sorted copy of the lines without duplicates.
+func sortedLines(lines []int) []int {
+	sorted := make([]int, len(lines))
+	copy(sorted, lines)
+	sort.Ints(sorted)
+
+	unique := sorted[:0]
+
+	for _, line := range sorted {
+		if len(unique) == 0 || line != unique[len(unique)-1] {
+			unique = append(unique, line)
+		}
+	}
+
+	return unique
+}
+
+func intersects(sortedLines []int, startLine, endLine int) bool {
+	i := sort.SearchInts(sortedLines, startLine)
+
+	return i < len(sortedLines) && sortedLines[i] <= endLine
+}
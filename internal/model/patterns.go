@@ -0,0 +1,113 @@
+package model
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var generatedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// matchesAny tells whether the file name matches any of the patterns.
+func matchesAny(patterns []string, fileName string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, fileName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPattern matches the file name relative to the code root against the
+// pattern, similar to .gitignore rules:
+//
+//   - a pattern without a slash matches a file or a directory at any depth,
+//     for example "*.pb.go" or "mocks";
+//   - a pattern with a slash matches from the code root, for example
+//     "cmd/*" or "/internal/mock.go";
+//   - "**" matches any number of directories, for example "**/testutil/**";
+//   - a directory match includes all the files in it.
+func matchPattern(pattern, fileName string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	segments := strings.Split(fileName, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range segments {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+
+	// a directory matches the files in it
+	for i := 1; i <= len(segments); i++ {
+		if matchSegments(patternSegments, segments[:i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// isGeneratedFile tells whether the file has the standard comment of the
+// generated code before the package clause.
+func isGeneratedFile(filename string) bool {
+	f, err := os.Open(filename) // nolint: gosec
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case generatedPattern.MatchString(line):
+			return true
+		case strings.HasPrefix(line, "package "):
+			return false
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+type blockPosition struct {
+	startLine, startCol int
+	endLine, endCol     int
+	numStmt             int
+}
+
+// parseProfiles reads all the coverage reports and binary coverage data
+// directories, and merges them into one list of profiles. Filenames can be
+// glob patterns; they are resolved relative to the code root, as well as the
+// directories.
+func parseProfiles(codeRoot string, filenames, coverDirs []string) ([]*cover.Profile, error) {
+	profiles := &profileSet{}
+
+	for _, filename := range filenames {
+		matches, err := expandProfileFilename(resolvePath(codeRoot, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			parsed, err := cover.ParseProfiles(match)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil, errNoCoverageFile{err}
+				}
+
+				return nil, errInvalidCoverageFile{err}
+			}
+
+			if err := profiles.add(match, parsed); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, dir := range coverDirs {
+		dir = resolvePath(codeRoot, dir)
+
+		parsed, err := readCoverDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := profiles.add(dir, parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	return profiles.list(), nil
+}
+
+// profileSet merges profiles coming from different sources. All the sources
+// must use the same coverage mode.
+type profileSet struct {
+	mode   string
+	byName map[string]*cover.Profile
+	merged []*cover.Profile
+}
+
+func (s *profileSet) add(source string, profiles []*cover.Profile) error {
+	if s.byName == nil {
+		s.byName = map[string]*cover.Profile{}
+	}
+
+	for _, p := range profiles {
+		if s.mode == "" {
+			s.mode = p.Mode
+		}
+
+		if p.Mode != s.mode {
+			return errConflictingModes{
+				fmt.Errorf("%s uses %q mode, expected %q", source, p.Mode, s.mode),
+			}
+		}
+
+		if existing, ok := s.byName[p.FileName]; ok {
+			mergeBlocks(existing, p.Blocks)
+			continue
+		}
+
+		s.byName[p.FileName] = p
+		s.merged = append(s.merged, p)
+	}
+
+	return nil
+}
+
+// list returns the merged profiles sorted by file name.
+func (s *profileSet) list() []*cover.Profile {
+	sort.Slice(s.merged, func(i, j int) bool {
+		return s.merged[i].FileName < s.merged[j].FileName
+	})
+
+	return s.merged
+}
+
+// resolvePath returns the name relative to the code root. Absolute names are
+// returned as is.
+func resolvePath(codeRoot, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return path.Join(codeRoot, name)
+}
+
+// expandProfileFilename resolves glob patterns. Filenames without patterns
+// are returned as is, so that missing files are reported when parsing.
+func expandProfileFilename(filename string) ([]string, error) {
+	matches, err := filepath.Glob(filename)
+	if err != nil {
+		return nil, errNoCoverageFile{fmt.Errorf("invalid pattern %s: %w", filename, err)}
+	}
+
+	if len(matches) == 0 {
+		return []string{filename}, nil
+	}
+
+	return matches, nil
+}
+
+// mergeBlocks adds the blocks to the profile. Counts of identical blocks are
+// summed in count and atomic modes, and combined in set mode.
+func mergeBlocks(p *cover.Profile, blocks []cover.ProfileBlock) {
+	indexes := make(map[blockPosition]int, len(p.Blocks))
+
+	for i, b := range p.Blocks {
+		indexes[positionOf(b)] = i
+	}
+
+	for _, b := range blocks {
+		i, ok := indexes[positionOf(b)]
+		if !ok {
+			indexes[positionOf(b)] = len(p.Blocks)
+			p.Blocks = append(p.Blocks, b)
+
+			continue
+		}
+
+		if p.Mode == "set" {
+			if b.Count > 0 {
+				p.Blocks[i].Count = 1
+			}
+		} else {
+			p.Blocks[i].Count += b.Count
+		}
+	}
+
+	sortBlocks(p.Blocks)
+}
+
+func sortBlocks(blocks []cover.ProfileBlock) {
+	sort.Slice(blocks, func(i, j int) bool {
+		bi, bj := blocks[i], blocks[j]
+		return bi.StartLine < bj.StartLine || (bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol)
+	})
+}
+
+func positionOf(b cover.ProfileBlock) blockPosition {
+	return blockPosition{
+		startLine: b.StartLine,
+		startCol:  b.StartCol,
+		endLine:   b.EndLine,
+		endCol:    b.EndCol,
+		numStmt:   b.NumStmt,
+	}
+}
@@ -0,0 +1,315 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// sourceResolver finds the source code of the files that don't belong to the
+// modules of the code root. This happens when the coverage is collected with
+// "-coverpkg" flag, or when some modules are replaced.
+type sourceResolver struct {
+	codeRoot string
+	modules  []Module
+	modCache string
+
+	// parsed go.mod files of every module, by module directory
+	goMods map[string]*goMod
+
+	// packageDirs keeps the answers of the go command between the loads
+	packageDirs *packageDirs
+}
+
+func newSourceResolver(codeRoot string, modules []Module, packageDirs *packageDirs) *sourceResolver {
+	r := &sourceResolver{
+		codeRoot:    codeRoot,
+		modules:     modules,
+		modCache:    moduleCacheDir(),
+		goMods:      make(map[string]*goMod, len(modules)),
+		packageDirs: packageDirs,
+	}
+
+	for _, mod := range modules {
+		goMod, err := readGoMod(path.Join(codeRoot, mod.Dir, "go.mod"))
+		if err != nil {
+			continue
+		}
+
+		r.goMods[mod.Dir] = goMod
+	}
+
+	return r
+}
+
+// resolve returns the location of every file, by its import path. Files that
+// can't be found are not included.
+func (r *sourceResolver) resolve(fileNames []string) map[string]string {
+	resolved := make(map[string]string, len(fileNames))
+
+	var unresolved []string
+
+	for _, fileName := range fileNames {
+		if filename, ok := r.resolveFile(fileName); ok {
+			resolved[fileName] = filename
+			continue
+		}
+
+		unresolved = append(unresolved, fileName)
+	}
+
+	if len(unresolved) == 0 {
+		return resolved
+	}
+
+	// the go command knows better, but it is slow, so it is the last resort
+	pkgs := make([]string, 0, len(unresolved))
+	for _, fileName := range unresolved {
+		pkgs = append(pkgs, path.Dir(fileName))
+	}
+
+	dirs := r.packageDirs.get(r.goModsState(), pkgs, r.listPackageDirs)
+
+	for _, fileName := range unresolved {
+		dir, ok := dirs[path.Dir(fileName)]
+		if !ok {
+			continue
+		}
+
+		filename := filepath.Join(dir, path.Base(fileName))
+		if fileExists(filename) {
+			resolved[fileName] = filename
+		}
+	}
+
+	return resolved
+}
+
+// resolveFile looks for the file in the replaced modules, in the vendor
+// directories and in the module cache.
+func (r *sourceResolver) resolveFile(fileName string) (string, bool) {
+	for _, mod := range r.modules {
+		goMod, ok := r.goMods[mod.Dir]
+		if !ok {
+			continue
+		}
+
+		modulePath, rest, ok := goMod.moduleOf(fileName)
+		if !ok {
+			continue
+		}
+
+		var candidates []string
+
+		version, required := goMod.requires[modulePath]
+
+		if repl, ok := goMod.replaces[modulePath]; ok && repl.appliesTo(version) {
+			if repl.isLocal() {
+				dir := repl.path
+				if !filepath.IsAbs(dir) {
+					dir = filepath.Join(r.codeRoot, mod.Dir, dir)
+				}
+
+				candidates = append(candidates, filepath.Join(dir, rest))
+			} else {
+				candidates = append(candidates, r.moduleCacheFile(repl.path, repl.version, rest))
+			}
+		}
+
+		candidates = append(candidates, filepath.Join(r.codeRoot, mod.Dir, "vendor", filepath.FromSlash(fileName)))
+
+		if required && !goMod.isExcluded(modulePath, version) {
+			candidates = append(candidates, r.moduleCacheFile(modulePath, version, rest))
+		}
+
+		for _, candidate := range candidates {
+			if candidate != "" && fileExists(candidate) {
+				return candidate, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// knowsModule tells whether any of the go.mod files requires or replaces the
+// module of the file.
+func (r *sourceResolver) knowsModule(fileName string) bool {
+	for _, goMod := range r.goMods {
+		if _, _, ok := goMod.moduleOf(fileName); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *sourceResolver) moduleCacheFile(modulePath, version, rest string) string {
+	if r.modCache == "" || version == "" {
+		return ""
+	}
+
+	return filepath.Join(r.modCache, escapeModulePath(modulePath)+"@"+escapeModulePath(version), filepath.FromSlash(rest))
+}
+
+// goModsState describes the go.mod and go.work files that the go command
+// uses to find the packages.
+func (r *sourceResolver) goModsState() string {
+	files := []string{filepath.Join(r.codeRoot, "go.work")}
+
+	for _, mod := range r.modules {
+		files = append(files, filepath.Join(r.codeRoot, mod.Dir, "go.mod"))
+	}
+
+	return filesFingerprint(files...)
+}
+
+// listPackageDirs asks the go command for the directories of the packages.
+func (r *sourceResolver) listPackageDirs(pkgs []string) map[string]string {
+	args := append([]string{"list", "-e", "-json"}, pkgs...)
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = r.codeRoot
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		log.Println("go list failed:", err, stderr.String())
+		return nil
+	}
+
+	dirs := map[string]string{}
+	dec := json.NewDecoder(&stdout)
+
+	for {
+		var pkg struct {
+			ImportPath string
+			Dir        string
+		}
+
+		if err := dec.Decode(&pkg); err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Println("failed to parse go list output:", err)
+			}
+
+			return dirs
+		}
+
+		if pkg.Dir != "" {
+			dirs[pkg.ImportPath] = pkg.Dir
+		}
+	}
+}
+
+// packageDirs remembers the package directories found by the go command, so
+// that it doesn't run again every time the profiles are reloaded. They are
+// forgotten when the go.mod or go.work files change.
+type packageDirs struct {
+	mu    sync.Mutex
+	state string
+
+	// dirs are the directories by import path, empty for the packages the
+	// go command could not find
+	dirs map[string]string
+}
+
+// get returns the directories of the packages, and lists the packages that
+// were not asked for yet.
+func (c *packageDirs) get(state string, pkgs []string, list func([]string) map[string]string) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dirs == nil || c.state != state {
+		c.state = state
+		c.dirs = map[string]string{}
+	}
+
+	var missing []string
+
+	for _, pkg := range pkgs {
+		if _, ok := c.dirs[pkg]; !ok {
+			missing = append(missing, pkg)
+			c.dirs[pkg] = ""
+		}
+	}
+
+	if len(missing) > 0 {
+		found := list(missing)
+
+		// the packages are asked again next time if the go command failed
+		if found == nil {
+			for _, pkg := range missing {
+				delete(c.dirs, pkg)
+			}
+		}
+
+		for pkg, dir := range found {
+			c.dirs[pkg] = dir
+		}
+	}
+
+	dirs := make(map[string]string, len(pkgs))
+
+	for _, pkg := range pkgs {
+		if dir := c.dirs[pkg]; dir != "" {
+			dirs[pkg] = dir
+		}
+	}
+
+	return dirs
+}
+
+// moduleCacheDir returns the location of the module cache without running
+// the go command.
+func moduleCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// escapeModulePath escapes the upper case letters the same way the module
+// cache does: every such letter is replaced with "!" and its lower case
+// version.
+func escapeModulePath(s string) string {
+	var sb strings.Builder
+
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			sb.WriteRune('!')
+			sb.WriteRune(unicode.ToLower(r))
+
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+func fileExists(filename string) bool {
+	fi, err := os.Stat(filename)
+	return err == nil && !fi.IsDir()
+}
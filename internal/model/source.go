@@ -0,0 +1,116 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/tools/cover"
+)
+
+// LineCoverage describes whether a part of a source code line is covered.
+type LineCoverage int
+
+// Possible coverage states of a line part. Neutral parts do not belong to
+// any statement block, such as comments or function signatures.
+const (
+	LineNeutral LineCoverage = iota
+	LineCovered
+	LineUncovered
+)
+
+// LinePart is a piece of a source code line with its coverage state.
+type LinePart struct {
+	Text     string
+	Coverage LineCoverage
+}
+
+// ReadLines reads the source code file line by line.
+func ReadLines(filename string) ([]string, error) {
+	f, err := os.Open(filename) // nolint: gosec
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errSourceFileNotFound{err}
+		}
+
+		return nil, errCantOpenSourceFile{fmt.Errorf("could not open file %s: %w", filename, err)}
+	}
+
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+
+	var lines []string
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, nil
+}
+
+// AnnotateLines walks the profile blocks along the source code lines, and
+// splits every line into parts with their coverage state. It returns an
+// error when the profile does not match the source code.
+func AnnotateLines(lines []string, profile *cover.Profile) (annotated [][]LinePart, err error) {
+	defer func() {
+		if rr := recover(); rr != nil {
+			err = fmt.Errorf("%s", rr)
+		}
+	}()
+
+	buf := make([][]LinePart, 0, len(lines))
+
+	for lineIdx, blockIdx := 0, 0; lineIdx < len(lines); lineIdx++ {
+		line, block := lines[lineIdx], profile.Blocks[blockIdx]
+
+		coverage := LineUncovered
+		if block.Count > 0 {
+			coverage = LineCovered
+		}
+
+		adjustedStartLine, adjustedEndLine := block.StartLine-1, block.EndLine-1
+
+		// before the first block - not covered
+		if lineIdx < adjustedStartLine {
+			buf = append(buf, []LinePart{{line, LineNeutral}})
+			continue
+		}
+
+		// first line - highlight from the start col
+		if lineIdx == adjustedStartLine {
+			buf = append(buf, []LinePart{
+				{line[:block.StartCol-1], LineNeutral},
+				{line[block.StartCol-1:], coverage},
+			})
+
+			continue
+		}
+
+		// inside any block - can be anything
+		if lineIdx >= adjustedStartLine && lineIdx <= adjustedEndLine {
+			// TODO: support end column as well
+			if block.NumStmt > 0 {
+				buf = append(buf, []LinePart{{line, coverage}})
+			} else {
+				buf = append(buf, []LinePart{{line, LineNeutral}})
+			}
+
+			continue
+		}
+
+		// after a block - might be the last block or just bump the block
+		if lineIdx > adjustedEndLine {
+			// when there are more blocks, bump the block
+			if blockIdx < len(profile.Blocks)-1 {
+				blockIdx++
+				lineIdx--
+			} else {
+				buf = append(buf, []LinePart{{line, LineNeutral}})
+			}
+		}
+	}
+
+	return buf, nil
+}
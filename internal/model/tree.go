@@ -0,0 +1,289 @@
+package model
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirNode is a directory of the tree view, with the aggregated coverage of
+// all the files in it and in its subdirectories. Directories without files
+// and with a single subdirectory are merged with it, so that the module
+// paths take a single line.
+type dirNode struct {
+	path  string
+	name  string
+	depth int
+
+	expanded bool
+	total    int64
+	covered  int64
+
+	dirs  []*dirNode
+	files []*coverProfile
+}
+
+func (d *dirNode) FilterValue() string { return d.path + "/" }
+
+func (d *dirNode) percentage() float64 {
+	return Percent(d.covered, d.total)
+}
+
+// treeFile is a file of the tree view, displayed below its directory.
+type treeFile struct {
+	*coverProfile
+	depth int
+}
+
+// buildTree arranges the files by their directories. The files keep their
+// order; the directories are sorted by name, or by coverage.
+func buildTree(items []list.Item, expanded map[string]bool, byCoverage bool) *dirNode {
+	root := &dirNode{expanded: true}
+	dirs := map[string]*dirNode{".": root}
+
+	var dirOf func(dir string) *dirNode
+
+	dirOf = func(dir string) *dirNode {
+		if d, ok := dirs[dir]; ok {
+			return d
+		}
+
+		// the files with absolute paths are attached to the root
+		parentDir := path.Dir(dir)
+		if parentDir == dir {
+			dirs[dir] = root
+			return root
+		}
+
+		parent := dirOf(parentDir)
+		d := &dirNode{path: dir, name: path.Base(dir)}
+		parent.dirs = append(parent.dirs, d)
+		dirs[dir] = d
+
+		return d
+	}
+
+	for _, item := range items {
+		f := item.(*coverProfile)
+		total, covered := CountStatements(f.profile)
+
+		d := dirOf(path.Dir(f.profile.FileName))
+		d.files = append(d.files, f)
+
+		for ; d != nil; d = dirs[path.Dir(d.path)] {
+			d.total += total
+			d.covered += covered
+
+			if d == root {
+				break
+			}
+		}
+	}
+
+	root.compact(expanded, byCoverage)
+
+	return root
+}
+
+func (d *dirNode) compact(expanded map[string]bool, byCoverage bool) {
+	for i, child := range d.dirs {
+		for len(child.files) == 0 && len(child.dirs) == 1 {
+			next := child.dirs[0]
+			next.name = child.name + "/" + next.name
+			child = next
+		}
+
+		child.expanded = expanded[child.path]
+		child.compact(expanded, byCoverage)
+		d.dirs[i] = child
+	}
+
+	sort.SliceStable(d.dirs, func(i, j int) bool {
+		if byCoverage {
+			return d.dirs[i].percentage() < d.dirs[j].percentage()
+		}
+
+		return d.dirs[i].name < d.dirs[j].name
+	})
+}
+
+// flatten returns the directories and the files that are visible in the tree:
+// the contents of the collapsed directories are hidden, unless all the nodes
+// are requested, for example for filtering.
+func (d *dirNode) flatten(items []list.Item, depth int, all bool) []list.Item {
+	for _, child := range d.dirs {
+		child.depth = depth
+		items = append(items, child)
+
+		if all || child.expanded {
+			items = child.flatten(items, depth+1, all)
+		}
+	}
+
+	for _, f := range d.files {
+		items = append(items, &treeFile{coverProfile: f, depth: depth})
+	}
+
+	return items
+}
+
+// walk calls the function for every directory of the tree.
+func (d *dirNode) walk(fn func(*dirNode)) {
+	for _, child := range d.dirs {
+		fn(child)
+		child.walk(fn)
+	}
+}
+
+// listItems returns the items of the list: the files, the files grouped by
+// their modules, or the nodes of the tree. All the nodes are used while
+// filtering, so that the files in the collapsed directories can be found;
+// the module headers are hidden.
+func (m *Model) listItems() []list.Item {
+	m.listFiltered = m.list.FilterState() != list.Unfiltered
+
+	if !m.treeView {
+		if m.listFiltered || len(m.modules) < 2 {
+			return m.items
+		}
+
+		return groupByModule(m.items, m.modules)
+	}
+
+	m.tree = buildTree(m.items, m.expandedDirs, m.sortByCoverage)
+
+	return m.tree.flatten(nil, 0, m.listFiltered)
+}
+
+// updateListItems replaces the items of the list, and keeps the selection on
+// the same file or directory, or on the closest visible directory.
+func (m *Model) updateListItems() tea.Cmd {
+	selected := selectedPath(m.list.SelectedItem())
+	cmd := m.list.SetItems(m.listItems())
+
+	if selected == "" || m.list.FilterState() != list.Unfiltered {
+		return cmd
+	}
+
+	// the same item is selected if it is visible, otherwise the closest
+	// visible directory, or the first file of the directory in the flat view
+	best, bestLen, descendant := -1, -1, -1
+
+	for i, item := range m.list.Items() {
+		p := selectedPath(item)
+
+		switch {
+		case p == selected:
+			m.list.Select(i)
+			return cmd
+		case strings.HasPrefix(selected, p+"/") && len(p) > bestLen:
+			best, bestLen = i, len(p)
+		case strings.HasPrefix(p, selected+"/") && descendant < 0:
+			descendant = i
+		}
+	}
+
+	if best < 0 {
+		best = descendant
+	}
+
+	if best >= 0 {
+		m.list.Select(best)
+	}
+
+	return cmd
+}
+
+// syncTreeFilter shows all the nodes of the tree when filtering starts, and
+// only the expanded ones when it ends. The module headers are hidden the same
+// way.
+func (m *Model) syncTreeFilter() tea.Cmd {
+	grouped := m.treeView || len(m.modules) > 1
+	if !grouped || m.listFiltered == (m.list.FilterState() != list.Unfiltered) {
+		return nil
+	}
+
+	return m.list.SetItems(m.listItems())
+}
+
+func (m *Model) toggleTree() tea.Cmd {
+	m.treeView = !m.treeView
+
+	status := "Flat view"
+	if m.treeView {
+		status = "Tree view"
+	}
+
+	return tea.Batch(m.updateListItems(), m.list.NewStatusMessage(status))
+}
+
+// toggleDir expands or collapses the directory. When it is selected while
+// filtering, the filter is reset and the directory is expanded in place.
+func (m *Model) toggleDir(d *dirNode) tea.Cmd {
+	if m.list.FilterState() != list.Unfiltered {
+		m.list.ResetFilter()
+		m.expandedDirs[d.path] = true
+
+		for dir := path.Dir(d.path); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			m.expandedDirs[dir] = true
+		}
+
+		cmd := m.list.SetItems(m.listItems())
+
+		for i, item := range m.list.Items() {
+			if selectedPath(item) == d.path {
+				m.list.Select(i)
+			}
+		}
+
+		return cmd
+	}
+
+	m.expandedDirs[d.path] = !d.expanded
+
+	return m.updateListItems()
+}
+
+// expandAll expands or collapses all the directories of the tree.
+func (m *Model) expandAll(expand bool) tea.Cmd {
+	if !m.treeView || m.tree == nil {
+		return nil
+	}
+
+	m.expandedDirs = map[string]bool{}
+
+	if expand {
+		m.tree.walk(func(d *dirNode) {
+			m.expandedDirs[d.path] = true
+		})
+	}
+
+	return m.updateListItems()
+}
+
+// selectedProfile returns the file of the item, in both flat and tree views.
+func selectedProfile(item list.Item) (*coverProfile, bool) {
+	switch item := item.(type) {
+	case *coverProfile:
+		return item, true
+	case *treeFile:
+		return item.coverProfile, true
+	}
+
+	return nil, false
+}
+
+func selectedPath(item list.Item) string {
+	if d, ok := item.(*dirNode); ok {
+		return d.path
+	}
+
+	if f, ok := selectedProfile(item); ok {
+		return f.profile.FileName
+	}
+
+	return ""
+}
@@ -0,0 +1,241 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchMsg carries the state of the watched files at the time of the check.
+// The state is a fingerprint built from file names, sizes and modification
+// times; any difference means that the files should be reloaded.
+type watchMsg struct {
+	profiles   string
+	sourceFile string
+	source     string
+}
+
+// reloadedProfiles are the profiles loaded again after a change.
+type reloadedProfiles struct {
+	*loadedProfiles
+}
+
+// reloadedFileContents is the content of the open file loaded again after a
+// change.
+type reloadedFileContents []string
+
+// watch checks the profiles and the open source file after the watch
+// interval.
+func (m *Model) watch() tea.Cmd {
+	codeRoot := m.codeRoot
+	profileFilenames := m.profileFilenames
+	coverDirs := m.coverDirs
+
+	var sourceFile string
+	if m.isCodeView() && m.openFile != "" {
+		sourceFile = m.sourceFile(m.openFile)
+	}
+
+	return tea.Tick(m.watchInterval, func(time.Time) tea.Msg {
+		return watchMsg{
+			profiles:   profilesFingerprint(codeRoot, profileFilenames, coverDirs),
+			sourceFile: sourceFile,
+			source:     filesFingerprint(sourceFile),
+		}
+	})
+}
+
+func (m *Model) onWatch(msg watchMsg) (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{m.watch()}
+
+	// the first check only records the initial state
+	if !m.watchStarted {
+		m.watchStarted = true
+		m.watchedProfiles = msg.profiles
+		m.watchedSourceFile, m.watchedSource = msg.sourceFile, msg.source
+
+		return m, tea.Batch(cmds...)
+	}
+
+	sourceChanged := msg.sourceFile != "" && msg.sourceFile == m.watchedSourceFile && msg.source != m.watchedSource
+
+	if msg.profiles != m.watchedProfiles {
+		cmds = append(cmds, m.reloadProfiles())
+	} else if sourceChanged {
+		cmds = append(cmds, m.reloadOpenFile())
+	}
+
+	m.watchedProfiles = msg.profiles
+	m.watchedSourceFile, m.watchedSource = msg.sourceFile, msg.source
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m *Model) reloadProfiles() tea.Cmd {
+	codeRoot := m.codeRoot
+	profileFilenames := m.profileFilenames
+
+	return func() tea.Msg {
+		loaded, err := m.readProfiles(codeRoot, profileFilenames, false)
+		if err != nil {
+			// the profile may be incomplete while it is being written; the
+			// next change triggers another attempt
+			log.Println("failed to reload profiles:", err)
+			return nil
+		}
+
+		return reloadedProfiles{loaded}
+	}
+}
+
+// onProfilesReloaded updates the list without losing the selected item, the
+// filter or the position in the open file. When the same files are found in
+// the same order, the items are updated in place.
+func (m *Model) onProfilesReloaded(loaded reloadedProfiles) (tea.Model, tea.Cmd) {
+	if len(loaded.profiles) == 0 {
+		return m, nil
+	}
+
+	m.applyProfiles(loaded.loadedProfiles)
+	items, title := m.buildItems(m.profiles)
+
+	var cmd tea.Cmd
+
+	switch {
+	case sameFiles(m.items, items):
+		for i, item := range items {
+			*m.items[i].(*coverProfile) = *item.(*coverProfile)
+		}
+
+		// the coverage of the directories and the modules changes along
+		// with the files
+		if m.treeView || len(m.modules) > 1 {
+			cmd = m.updateListItems()
+		}
+	default:
+		m.items = items
+		cmd = m.updateListItems()
+	}
+
+	if title != "" {
+		m.list.Title = title
+	}
+
+	// the profiles could not be loaded before the change
+	if m.isErrorView() {
+		m.activeView = activeViewList
+	}
+
+	// the functions follow the coverage of the files
+	if m.funcs != nil {
+		cmd = tea.Batch(cmd, m.loadFuncs())
+	}
+
+	if m.isCodeView() {
+		return m, tea.Batch(cmd, m.reloadOpenFile())
+	}
+
+	return m, cmd
+}
+
+// reloadOpenFile loads the open file again using the latest profile.
+func (m *Model) reloadOpenFile() tea.Cmd {
+	for _, item := range m.items {
+		item := item.(*coverProfile)
+		if item.profile.FileName != m.openFile {
+			continue
+		}
+
+		load := loadFile(m.sourceFile(m.openFile), item.profile)
+
+		return func() tea.Msg {
+			msg := load()
+			if content, ok := msg.(fileContents); ok {
+				return reloadedFileContents(content)
+			}
+
+			log.Println("failed to reload file:", msg)
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) onFileContentReloaded(content []string) (tea.Model, tea.Cmd) {
+	if m.isCodeView() {
+		m.code.ReplaceContent(content)
+	}
+
+	return m, nil
+}
+
+func sameFiles(a, b []list.Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].(*coverProfile).profile.FileName != b[i].(*coverProfile).profile.FileName {
+			return false
+		}
+	}
+
+	return true
+}
+
+// profilesFingerprint returns the state of all the coverage reports and the
+// files in the binary coverage data directories.
+func profilesFingerprint(codeRoot string, profileFilenames, coverDirs []string) string {
+	var files []string
+
+	for _, filename := range profileFilenames {
+		matches, err := expandProfileFilename(resolvePath(codeRoot, filename))
+		if err == nil {
+			files = append(files, matches...)
+		}
+	}
+
+	for _, dir := range coverDirs {
+		dir = resolvePath(codeRoot, dir)
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			files = append(files, dir)
+			continue
+		}
+
+		for _, e := range entries {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return filesFingerprint(files...)
+}
+
+func filesFingerprint(files ...string) string {
+	var sb strings.Builder
+
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+
+		fi, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s: missing\n", file)
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%s: %d %d\n", file, fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,289 @@
+package program
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/orlangure/gocovsh/internal/model"
+	"github.com/orlangure/gocovsh/internal/report"
+)
+
+// command is an optional first argument of the program that replaces the
+// interactive viewer with another action. Commands accept the same options as
+// the program itself, and may register additional ones.
+type command struct {
+	name        string
+	description string
+	flags       func(p *Program)
+	prepare     func(p *Program) (cleanup func(), err error)
+	run         func(p *Program, m *model.Model) error
+
+	// standalone commands run instead of loading the coverage data
+	standalone func(p *Program) error
+}
+
+var commands = []*command{
+	{
+		name:        "run",
+		description: "run go test with the given packages and flags, then open the viewer",
+		prepare:     (*Program).runTests,
+		run:         (*Program).runViewer,
+	},
+	{
+		name:        "html",
+		description: "generate a static HTML report in the directory set by -o",
+		flags:       (*Program).htmlFlags,
+		run:         (*Program).runHTML,
+	},
+	{
+		name:        "check",
+		description: "fail with a non-zero exit code if the coverage is below the thresholds",
+		flags:       (*Program).checkFlags,
+		run:         (*Program).runCheck,
+	},
+	{
+		name:        "doctor",
+		description: "check that the coverage data matches the source code, and explain how to fix it",
+		run:         (*Program).runDoctor,
+	},
+	{
+		name:        "config",
+		description: "print the effective configuration and where every value comes from",
+		flags:       (*Program).commandFlags,
+		standalone:  (*Program).printConfig,
+	},
+}
+
+// Exit codes of the check command. They are combined when several
+// categories fail at once.
+const (
+	ExitCodeTotal   = 2
+	ExitCodePackage = 4
+	ExitCodeFile    = 8
+	ExitCodePatch   = 16
+)
+
+var checkExitCodes = map[report.CheckCategory]int{
+	report.CheckTotal:   ExitCodeTotal,
+	report.CheckPackage: ExitCodePackage,
+	report.CheckFile:    ExitCodeFile,
+	report.CheckPatch:   ExitCodePatch,
+}
+
+// ExitError is returned when the program should exit with a specific exit
+// code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// runTests executes "go test" with the remaining arguments, writing the
+// coverage profile into a temporary file that replaces the profiles set by
+// the flags. Test output is streamed to stderr while the tests are running,
+// so that the reports written to stdout stay valid, and the failing packages
+// are listed at the end.
+func (p *Program) runTests() (func(), error) {
+	dir, err := os.MkdirTemp("", "gocovsh")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	profile := filepath.Join(dir, "coverage.out")
+
+	args := p.flagSet.Args()
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+
+	cmd := exec.Command("go", append([]string{"test", "-coverprofile", profile}, args...)...) // nolint: gosec
+
+	failed, err := p.streamTestOutput(cmd)
+	if len(failed) > 0 {
+		fmt.Fprintf(p.errOutput, "\nFailing packages:\n")
+
+		for _, pkg := range failed {
+			fmt.Fprintf(p.errOutput, "\t%s\n", pkg)
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		p.testExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to run tests: %w", err)
+	}
+
+	p.profileFilenames = stringsFlag{profile}
+
+	return cleanup, nil
+}
+
+// streamTestOutput runs the command, copies its output and returns the
+// packages reported as failing by "go test".
+func (p *Program) streamTestOutput(cmd *exec.Cmd) ([]string, error) {
+	r, w := io.Pipe()
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		err := cmd.Wait()
+		_ = w.Close()
+		done <- err
+	}()
+
+	var failed []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(p.errOutput, line)
+
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "FAIL" {
+			failed = append(failed, fields[1])
+		}
+	}
+
+	// drain the output in case of a scanner error, so that the command can
+	// exit
+	_, _ = io.Copy(io.Discard, r)
+
+	return failed, <-done
+}
+
+// runViewer shows the coverage of the tests executed by the run command,
+// either in the interactive viewer or as a report. Failing tests make the
+// program exit with the exit code of "go test".
+func (p *Program) runViewer(m *model.Model) error {
+	err := p.show(m)
+	if p.testExitCode == 0 {
+		return err
+	}
+
+	if err != nil {
+		err = fmt.Errorf("tests failed: %w", err)
+	} else {
+		err = errors.New("tests failed")
+	}
+
+	return &ExitError{Code: p.testExitCode, Err: err}
+}
+
+func (p *Program) htmlFlags() {
+	p.flagSet.StringVar(&p.outputDir, "o", "", "output directory of the HTML report")
+}
+
+func (p *Program) checkFlags() {
+	p.flagSet.Float64Var(&p.thresholds.Total, "min-total", 0, "minimum total coverage percentage")
+	p.flagSet.Float64Var(&p.thresholds.Package, "min-package", 0, "minimum coverage percentage of every package")
+	p.flagSet.Float64Var(&p.thresholds.File, "min-file", 0, "minimum coverage percentage of every file")
+	p.flagSet.Float64Var(
+		&p.thresholds.Patch, "min-patch", 0,
+		"minimum coverage percentage of the changed statements, requires a diff on stdin",
+	)
+}
+
+func (p *Program) runCheck(m *model.Model) error {
+	c, err := p.loadCoverage(m)
+	if err != nil {
+		return err
+	}
+
+	violations, err := report.Check(p.output, c, p.thresholds)
+	if err != nil {
+		return fmt.Errorf("failed to check coverage: %w", err)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	code := 0
+	for _, v := range violations {
+		code |= checkExitCodes[v.Category]
+	}
+
+	return &ExitError{
+		Code: code,
+		Err:  fmt.Errorf("coverage is below the thresholds: %d violations", len(violations)),
+	}
+}
+
+// runDoctor reports the profile entries that don't match the source code:
+// missing files, files modified after the coverage data was written, blocks
+// outside of the file and files of unknown modules. Every entry is checked,
+// regardless of the filters that select the files to show.
+func (p *Program) runDoctor(m *model.Model) error {
+	profiles, problems, err := m.DiagnoseProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	files := "files"
+	if len(profiles) == 1 {
+		files = "file"
+	}
+
+	if len(problems) == 0 {
+		_, err := fmt.Fprintf(p.output, "Checked %d %s, no problems found\n", len(profiles), files)
+		return err
+	}
+
+	fmt.Fprintf(p.output, "Checked %d %s, found problems in %d:\n", len(profiles), files, len(problems))
+
+	for _, problem := range problems {
+		fmt.Fprintf(p.output, "\n%s: %s\n", problem.FileName, problem.Kind)
+		fmt.Fprintf(p.output, "\t%s\n", problem.Details)
+		fmt.Fprintf(p.output, "\tfix: %s\n", problem.Fix)
+	}
+
+	return &ExitError{
+		Code: 1,
+		Err:  fmt.Errorf("coverage data does not match the source code of %d files", len(problems)),
+	}
+}
+
+func (p *Program) runHTML(m *model.Model) error {
+	if p.outputDir == "" {
+		return errors.New("output directory is required, use -o to set it")
+	}
+
+	c, err := p.loadCoverage(m)
+	if err != nil {
+		return err
+	}
+
+	if err := report.HTML(p.outputDir, c); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+
+	_, err = fmt.Fprintf(p.output, "HTML report written to %s\n", p.outputDir)
+
+	return err
+}
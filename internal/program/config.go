@@ -0,0 +1,415 @@
+package program
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/orlangure/gocovsh/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix          = "GOCOVSH_"
+	userConfigFilename = "config.yaml"
+
+	// keysSetting holds the key bindings in the configuration files.
+	keysSetting = "keys"
+
+	sourceFlag    = "flag"
+	sourceDefault = "default"
+)
+
+// projectConfigFilenames are looked up in the code root, in this order.
+var projectConfigFilenames = []string{".gocovsh.yaml", ".gocovsh.yml"}
+
+// commandLineOnly are the flags that make no sense in the configuration:
+// they either describe a single run, or are needed to find the project
+// configuration file.
+var commandLineOnly = map[string]bool{
+	"version":   true,
+	"root":      true,
+	"diff":      true,
+	"diff-base": true,
+	"staged":    true,
+}
+
+// settingAliases map the alternative flag names to the setting they change.
+var settingAliases = map[string]string{
+	"format": "report",
+}
+
+// configFile is a project or user configuration file. Its settings use the
+// names of the flags, and the key bindings are set in the "keys" section.
+type configFile struct {
+	kind     string
+	path     string
+	found    bool
+	settings map[string][]string
+	keys     map[string][]string
+}
+
+func (f *configFile) source() string {
+	return f.kind + " file " + f.path
+}
+
+// config is the effective configuration with the source of every value.
+type config struct {
+	files      []*configFile
+	sources    map[string]string
+	keys       map[string][]string
+	keySources map[string]string
+
+	// warnings are the problems of the configuration that don't stop the
+	// program.
+	warnings []string
+}
+
+// loadConfig applies the settings from the environment and the configuration
+// files to the flags that are not set on the command line. Every setting
+// comes from a single source: the flags take precedence over the environment
+// variables, which take precedence over the project file and then the user
+// file. Repeatable settings, such as "exclude", are not merged across the
+// sources.
+func (p *Program) loadConfig() error {
+	explicit := map[string]bool{}
+
+	p.flagSet.Visit(func(f *flag.Flag) {
+		explicit[settingName(f.Name)] = true
+	})
+
+	files, err := p.readConfigFiles()
+	if err != nil {
+		return err
+	}
+
+	c := &config{
+		files:      files,
+		sources:    map[string]string{},
+		keys:       model.DefaultKeyBindings(),
+		keySources: map[string]string{},
+	}
+
+	var settings []*flag.Flag
+
+	p.flagSet.VisitAll(func(f *flag.Flag) {
+		if !commandLineOnly[f.Name] && settingAliases[f.Name] == "" {
+			settings = append(settings, f)
+		}
+	})
+
+	for _, f := range settings {
+		if err := c.apply(f, explicit[f.Name]); err != nil {
+			return err
+		}
+	}
+
+	for _, action := range model.KeyActions {
+		c.keySources[action] = sourceDefault
+
+		for _, file := range files {
+			if keys, ok := file.keys[action]; ok {
+				c.keys[action] = keys
+				c.keySources[action] = file.source()
+
+				break
+			}
+		}
+	}
+
+	if err := model.CheckKeyBindings(c.keys); err != nil {
+		return fmt.Errorf("invalid key bindings: %w", err)
+	}
+
+	p.config = c
+
+	return nil
+}
+
+// apply sets the flag from the highest priority source of its value.
+func (c *config) apply(f *flag.Flag, explicit bool) error {
+	if explicit {
+		c.sources[f.Name] = sourceFlag
+		return nil
+	}
+
+	env := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+
+	if value, ok := os.LookupEnv(env); ok {
+		values := []string{value}
+		if isRepeatable(f) {
+			values = strings.Split(value, ",")
+		}
+
+		c.sources[f.Name] = "env " + env
+
+		return setFlag(f, values, env)
+	}
+
+	for _, file := range c.files {
+		if values, ok := file.settings[f.Name]; ok {
+			c.sources[f.Name] = file.source()
+			return setFlag(f, values, file.path)
+		}
+	}
+
+	c.sources[f.Name] = sourceDefault
+
+	return nil
+}
+
+func setFlag(f *flag.Flag, values []string, source string) error {
+	if len(values) > 1 && !isRepeatable(f) {
+		return fmt.Errorf("%s: %q expects a single value, got %d", source, f.Name, len(values))
+	}
+
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %q: %w", source, value, f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func isRepeatable(f *flag.Flag) bool {
+	_, ok := f.Value.(*stringsFlag)
+	return ok
+}
+
+func settingName(name string) string {
+	if alias, ok := settingAliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+// readConfigFiles reads the project configuration file from the code root and
+// the user configuration file, in the order of their precedence.
+func (p *Program) readConfigFiles() ([]*configFile, error) {
+	root := p.codeRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find code root: %w", err)
+		}
+
+		if root, _ = findCodeRoot(wd); root == "" {
+			root = wd
+		}
+	}
+
+	project := &configFile{kind: "project", path: filepath.Join(root, projectConfigFilenames[0])}
+
+	for _, name := range projectConfigFilenames {
+		if fi, err := os.Stat(filepath.Join(root, name)); err == nil && !fi.IsDir() {
+			project.path = filepath.Join(root, name)
+			break
+		}
+	}
+
+	files := []*configFile{project}
+
+	if p.userConfigDir != "" {
+		files = append(files, &configFile{kind: "user", path: filepath.Join(p.userConfigDir, userConfigFilename)})
+	}
+
+	known := knownSettings()
+
+	for _, f := range files {
+		if err := f.read(known); err != nil {
+			return nil, fmt.Errorf("failed to read configuration: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+// knownSettings returns the names of the flags of every command, so that the
+// configuration files can include the settings of the commands that are not
+// running.
+func knownSettings() map[string]bool {
+	scratch := New(WithFlagSet(flag.NewFlagSet("gocovsh", flag.ContinueOnError), nil))
+	scratch.commandFlags()
+
+	known := map[string]bool{}
+
+	scratch.flagSet.VisitAll(func(f *flag.Flag) {
+		if !commandLineOnly[f.Name] {
+			known[f.Name] = true
+		}
+	})
+
+	return known
+}
+
+func (f *configFile) read(known map[string]bool) error {
+	bs, err := os.ReadFile(f.path) // nolint: gosec
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	f.found = true
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.settings = make(map[string][]string, len(raw))
+
+	for name, value := range raw {
+		if name == keysSetting {
+			if err := f.readKeys(value); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !known[name] {
+			return fmt.Errorf("%s: unknown setting %q", f.path, name)
+		}
+
+		values, err := scalarValues(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", f.path, name, err)
+		}
+
+		f.settings[settingName(name)] = values
+	}
+
+	return nil
+}
+
+func (f *configFile) readKeys(value interface{}) error {
+	bindings, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: %q must map the actions to the keys", f.path, keysSetting)
+	}
+
+	f.keys = make(map[string][]string, len(bindings))
+
+	for action, keys := range bindings {
+		if !isKeyAction(action) {
+			return fmt.Errorf(
+				"%s: unknown key action %q, use one of: %s",
+				f.path, action, strings.Join(model.KeyActions, ", "),
+			)
+		}
+
+		values, err := scalarValues(keys)
+		if err != nil || len(values) == 0 {
+			return fmt.Errorf("%s: keys of %q must be a key or a list of keys", f.path, action)
+		}
+
+		f.keys[action] = values
+	}
+
+	return nil
+}
+
+func isKeyAction(action string) bool {
+	for _, a := range model.KeyActions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scalarValues converts a single YAML value or a list of values into the
+// strings accepted by the flags.
+func scalarValues(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+
+	values := make([]string, 0, len(list))
+
+	for _, v := range list {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			values = append(values, v)
+		case bool:
+			values = append(values, strconv.FormatBool(v))
+		case int:
+			values = append(values, strconv.Itoa(v))
+		case float64:
+			values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("unsupported value %v", v)
+		}
+	}
+
+	return values, nil
+}
+
+// commandFlags registers the options of all the commands.
+func (p *Program) commandFlags() {
+	p.checkFlags()
+	p.htmlFlags()
+}
+
+// printConfig writes the configuration files, and the effective value of
+// every setting with its source.
+func (p *Program) printConfig() error {
+	w := tabwriter.NewWriter(p.output, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Configuration files:")
+
+	for _, f := range p.config.files {
+		status := ""
+		if !f.found {
+			status = "(not found)"
+		}
+
+		fmt.Fprintf(w, "\t%s\t%s\t%s\n", f.kind, f.path, status)
+	}
+
+	fmt.Fprintln(w, "\nSettings:")
+
+	names := make([]string, 0, len(p.config.sources))
+	for name := range p.config.sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := p.flagSet.Lookup(name).Value.String()
+		fmt.Fprintf(w, "\t%s\t%s\t%s\n", name, value, p.config.sources[name])
+	}
+
+	for _, action := range model.KeyActions {
+		keys := strings.Join(p.config.keys[action], ", ")
+		fmt.Fprintf(w, "\t%s.%s\t%s\t%s\n", keysSetting, action, keys, p.config.keySources[action])
+	}
+
+	if len(p.config.warnings) > 0 {
+		fmt.Fprintln(w, "\nWarnings:")
+
+		for _, warning := range p.config.warnings {
+			fmt.Fprintf(w, "\t%s\n", warning)
+		}
+	}
+
+	return w.Flush()
+}
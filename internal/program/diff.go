@@ -0,0 +1,469 @@
+package program
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	hunkHeaderPattern = regexp.MustCompile(`^(@@+) -\d+(?:,(\d+))? (?:-\d+(?:,\d+)? )*\+(\d+)(?:,(\d+))? @@+`)
+	nameStatusPattern = regexp.MustCompile(`^[ACDMRTUXB]\d*$`)
+)
+
+// changes are the files and lines found in the input: a diff, a list of files
+// or the output of "git diff --name-status".
+type changes struct {
+	// files are the names of the files that exist after the change.
+	files []string
+
+	// lines are the added lines of every file, when the input is a diff.
+	lines map[string][]int
+}
+
+// parseChanges detects the format of the input and reads the changes from it.
+func parseChanges(s string) (*changes, error) {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+
+	switch {
+	case strings.Contains(s, "\x00"):
+		return parseNULSeparated(s), nil
+	case isDiff(lines):
+		return parseDiff(lines)
+	case isNameStatus(lines):
+		return parseNameStatus(lines)
+	}
+
+	c := &changes{}
+
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			c.files = append(c.files, unquoteName(line))
+		}
+	}
+
+	return c, nil
+}
+
+// isDiff tells whether the input is a diff: either produced by git, or a
+// plain unified diff that starts with the file names.
+func isDiff(lines []string) bool {
+	for i, line := range lines {
+		if strings.HasPrefix(line, "diff ") {
+			return true
+		}
+
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNameStatus(lines []string) bool {
+	found := false
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || !nameStatusPattern.MatchString(fields[0]) {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
+
+// parseNameStatus reads the output of "git diff --name-status". Deleted files
+// are skipped, renamed and copied files are used with their new names.
+func parseNameStatus(lines []string) (*changes, error) {
+	c := &changes{}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if (fields[0][0] == 'R' || fields[0][0] == 'C') && len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected the old and the new file names: %q", i+1, line)
+		}
+
+		if fields[0][0] != 'D' {
+			c.files = append(c.files, unquoteName(fields[len(fields)-1]))
+		}
+	}
+
+	return c, nil
+}
+
+// parseNULSeparated reads the file names produced by "git diff -z", with or
+// without the status of every file.
+func parseNULSeparated(s string) *changes {
+	tokens := strings.Split(strings.Trim(s, "\x00\n"), "\x00")
+	c := &changes{}
+
+	if !nameStatusPattern.MatchString(tokens[0]) {
+		for _, token := range tokens {
+			if token = strings.TrimSpace(token); token != "" {
+				c.files = append(c.files, token)
+			}
+		}
+
+		return c
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		status := tokens[i]
+
+		names := 1
+		if status != "" && (status[0] == 'R' || status[0] == 'C') {
+			names = 2
+		}
+
+		i += names
+		if i >= len(tokens) {
+			break
+		}
+
+		if status == "" || status[0] != 'D' {
+			c.files = append(c.files, tokens[i])
+		}
+	}
+
+	return c
+}
+
+// diffFile is a single file of the diff.
+type diffFile struct {
+	oldName, newName string
+	deleted          bool
+	prefixed         bool
+	hasHunks         bool
+	added            []int
+}
+
+// diffParser reads unified diffs produced by git, including renames, copies,
+// combined diffs of merges and diffs without "a/" and "b/" prefixes, as well
+// as the diffs produced by other tools. Hunks are read using the line counts
+// from their headers, so any text after the diff is ignored.
+type diffParser struct {
+	lines []string
+	pos   int
+	files []*diffFile
+}
+
+// parseDiff reads the files and the added lines of the diff. A patch with
+// several commits may change the same file many times: its lines are merged,
+// sorted and numbered as in the hunk that added them.
+func parseDiff(lines []string) (*changes, error) {
+	d := &diffParser{lines: lines}
+
+	if err := d.parse(); err != nil {
+		return nil, err
+	}
+
+	c := &changes{lines: map[string][]int{}}
+	seen := map[string]bool{}
+
+	for _, f := range d.files {
+		if f.deleted {
+			continue
+		}
+
+		if f.newName == "" {
+			f.newName = f.oldName
+		}
+
+		if f.newName == "" {
+			return nil, fmt.Errorf("diff without file names")
+		}
+
+		if !seen[f.newName] {
+			c.files = append(c.files, f.newName)
+			seen[f.newName] = true
+		}
+
+		if len(f.added) > 0 {
+			c.lines[f.newName] = append(c.lines[f.newName], f.added...)
+		}
+	}
+
+	for file, added := range c.lines {
+		c.lines[file] = sortedUnique(added)
+	}
+
+	return c, nil
+}
+
+// sortedUnique sorts the line numbers and removes the duplicates.
+func sortedUnique(lines []int) []int {
+	sort.Ints(lines)
+
+	unique := lines[:0]
+
+	for _, line := range lines {
+		if len(unique) == 0 || line != unique[len(unique)-1] {
+			unique = append(unique, line)
+		}
+	}
+
+	return unique
+}
+
+func (d *diffParser) current() *diffFile {
+	if len(d.files) == 0 {
+		return nil
+	}
+
+	return d.files[len(d.files)-1]
+}
+
+func (d *diffParser) parse() error {
+	for ; d.pos < len(d.lines); d.pos++ {
+		line := d.lines[d.pos]
+		f := d.current()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			d.files = append(d.files, parseGitDiffHeader(strings.TrimPrefix(line, "diff --git ")))
+
+		case strings.HasPrefix(line, "diff --cc "), strings.HasPrefix(line, "diff --combined "):
+			name := unquoteName(line[strings.Index(line[5:], " ")+6:])
+			d.files = append(d.files, &diffFile{oldName: name, newName: name})
+
+		case strings.HasPrefix(line, "diff "):
+			d.files = append(d.files, &diffFile{})
+
+		case d.startsFile(d.pos):
+			if f == nil || f.hasHunks {
+				f = &diffFile{}
+				d.files = append(d.files, f)
+			}
+
+			f.oldName = d.headerName(f, strings.TrimPrefix(line, "--- "), "a/")
+
+		case strings.HasPrefix(line, "+++ ") && f != nil && !f.hasHunks:
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				f.deleted = true
+				continue
+			}
+
+			f.newName = d.headerName(f, name, "b/")
+
+		case f != nil && !f.hasHunks && (strings.HasPrefix(line, "rename from ") || strings.HasPrefix(line, "copy from ")):
+			f.oldName = unquoteName(line[strings.Index(line, " from ")+6:])
+
+		case f != nil && !f.hasHunks && (strings.HasPrefix(line, "rename to ") || strings.HasPrefix(line, "copy to ")):
+			f.newName = unquoteName(line[strings.Index(line, " to ")+4:])
+
+		case f != nil && strings.HasPrefix(line, "deleted file mode"):
+			f.deleted = true
+
+		case strings.HasPrefix(line, "@@"):
+			if f == nil {
+				return fmt.Errorf("line %d: hunk without a file header", d.pos+1)
+			}
+
+			if err := d.parseHunk(f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// startsFile tells whether the line is the first line of the next file: the
+// "---" line followed by the "+++" one, and not a removed line that starts
+// with "--".
+func (d *diffParser) startsFile(pos int) bool {
+	return strings.HasPrefix(d.lines[pos], "--- ") &&
+		pos+1 < len(d.lines) && strings.HasPrefix(d.lines[pos+1], "+++ ")
+}
+
+// headerName returns the file name from the "---" or "+++" line, without the
+// timestamp added by some tools and without the prefix added by git.
+func (d *diffParser) headerName(f *diffFile, name, prefix string) string {
+	if name == "/dev/null" {
+		return ""
+	}
+
+	if i := strings.Index(name, "\t"); i >= 0 {
+		name = name[:i]
+	}
+
+	name = unquoteName(name)
+
+	if f.prefixed || d.prefixedHeaders() {
+		name = strings.TrimPrefix(name, prefix)
+	}
+
+	return name
+}
+
+// prefixedHeaders tells whether both "---" and "+++" names of the current
+// file use git prefixes. It is used for the diffs without "diff --git" line.
+func (d *diffParser) prefixedHeaders() bool {
+	oldLine, newLine := d.lines[d.pos], ""
+
+	if strings.HasPrefix(oldLine, "+++ ") && d.pos > 0 {
+		oldLine, newLine = d.lines[d.pos-1], oldLine
+	} else if d.pos+1 < len(d.lines) {
+		newLine = d.lines[d.pos+1]
+	}
+
+	oldName := unquoteName(strings.TrimPrefix(oldLine, "--- "))
+	newName := unquoteName(strings.TrimPrefix(newLine, "+++ "))
+
+	return (oldName == "/dev/null" || strings.HasPrefix(oldName, "a/")) &&
+		(newName == "/dev/null" || strings.HasPrefix(newName, "b/"))
+}
+
+// parseHunk reads the hunk that starts at the current line, and records the
+// numbers of the added lines. In combined diffs, every line starts with one
+// column per parent.
+func (d *diffParser) parseHunk(f *diffFile) error {
+	header := d.lines[d.pos]
+
+	m := hunkHeaderPattern.FindStringSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("line %d: invalid hunk header: %q", d.pos+1, header)
+	}
+
+	columns := len(m[1]) - 1
+	newLine, _ := strconv.Atoi(m[3])
+	oldRemaining, remaining := hunkLength(m[2]), hunkLength(m[4])
+
+	f.hasHunks = true
+
+	for d.pos+1 < len(d.lines) {
+		line := d.lines[d.pos+1]
+
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file"
+			d.pos++
+			continue
+		}
+
+		// the old lines are only counted in regular diffs; in combined diffs,
+		// the removed lines at the end of the hunk are read until the next
+		// file or hunk starts
+		if remaining == 0 && (columns == 1 && oldRemaining == 0 || d.startsFile(d.pos+1)) {
+			break
+		}
+
+		// some editors strip the trailing space of empty context lines
+		if line == "" && remaining > 0 {
+			line = strings.Repeat(" ", columns)
+		}
+
+		if len(line) < columns || strings.Trim(line[:columns], " +-") != "" {
+			break
+		}
+
+		markers := line[:columns]
+		d.pos++
+
+		if !strings.Contains(markers, "+") {
+			oldRemaining--
+		}
+
+		if strings.Contains(markers, "-") {
+			continue
+		}
+
+		if remaining == 0 {
+			return fmt.Errorf("line %d: hunk is longer than its header says: %q", d.pos+1, header)
+		}
+
+		if strings.Contains(markers, "+") {
+			f.added = append(f.added, newLine)
+		}
+
+		newLine++
+		remaining--
+	}
+
+	if remaining > 0 {
+		return fmt.Errorf("line %d: hunk is shorter than its header says: %q", d.pos+1, header)
+	}
+
+	return nil
+}
+
+// hunkLength returns the number of lines in the hunk range, which is 1 when
+// it is omitted from the header.
+func hunkLength(s string) int {
+	if s == "" {
+		return 1
+	}
+
+	n, _ := strconv.Atoi(s)
+
+	return n
+}
+
+// parseGitDiffHeader reads the file names from "diff --git" line. The names
+// are only reliable when they are the same; otherwise, they are updated by
+// the following lines.
+func parseGitDiffHeader(s string) *diffFile {
+	f := &diffFile{}
+
+	if strings.HasPrefix(s, `"`) {
+		if oldName, rest, ok := cutQuoted(s); ok {
+			f.oldName, f.newName = oldName, unquoteName(strings.TrimSpace(rest))
+		}
+	} else if half := len(s) / 2; len(s)%2 == 1 && s[half] == ' ' && s[:half] == s[half+1:] {
+		// "diff --git file.go file.go", produced with "--no-prefix"
+		f.oldName, f.newName = s[:half], s[half+1:]
+	} else if strings.HasPrefix(s, "a/") && strings.Contains(s, " b/") {
+		i := strings.LastIndex(s, " b/")
+		f.oldName, f.newName = s[:i], s[i+1:]
+	}
+
+	if strings.HasPrefix(f.oldName, "a/") && strings.HasPrefix(f.newName, "b/") {
+		f.prefixed = true
+		f.oldName, f.newName = f.oldName[2:], f.newName[2:]
+	}
+
+	return f
+}
+
+// cutQuoted splits the quoted file name from the rest of the string.
+func cutQuoted(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			name, err := strconv.Unquote(s[:i+1])
+			return name, s[i+1:], err == nil
+		}
+	}
+
+	return "", "", false
+}
+
+// unquoteName returns the file name quoted by git when it has special
+// characters, or the name as is.
+func unquoteName(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+
+	if name, err := strconv.Unquote(s); err == nil {
+		return name
+	}
+
+	return s
+}
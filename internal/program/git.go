@@ -0,0 +1,44 @@
+package program
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitDiff runs git to compute the diff requested by the flags: the changes
+// since the merge-base of the base ref and HEAD, the staged changes, or both.
+// File names are relative to the code root.
+func (p *Program) gitDiff() (string, error) {
+	args := []string{"-C", p.codeRoot, "diff", "--relative", "--no-color", "--no-ext-diff"}
+
+	if p.staged {
+		args = append(args, "--cached")
+	}
+
+	if p.diffBase != "" {
+		base, err := git("-C", p.codeRoot, "merge-base", p.diffBase, "HEAD")
+		if err != nil {
+			return "", err
+		}
+
+		args = append(args, strings.TrimSpace(base))
+	}
+
+	return git(args...)
+}
+
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -57,6 +57,14 @@ func WithOutput(w io.Writer) Option {
 	}
 }
 
+// WithErrorOutput sets the stderr writer for the program, which receives the
+// output of the tests executed by the run command.
+func WithErrorOutput(w io.Writer) Option {
+	return func(p *Program) {
+		p.errOutput = w
+	}
+}
+
 // WithInput sets the stdin for the program. This should be used for testing
 // the features that read from stdin.
 func WithInput(file fs.File) Option {
@@ -64,3 +72,11 @@ func WithInput(file fs.File) Option {
 		p.input = file
 	}
 }
+
+// WithUserConfigDir sets the directory of the user configuration file, which
+// is used when the project does not set the options.
+func WithUserConfigDir(dir string) Option {
+	return func(p *Program) {
+		p.userConfigDir = dir
+	}
+}
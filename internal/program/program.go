@@ -10,22 +10,42 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/orlangure/gocovsh/internal/model"
-	"github.com/waigani/diffparser"
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/orlangure/gocovsh/internal/styles"
 )
 
 const (
 	defaultProfileFilename = "coverage.out"
+	watchInterval          = time.Second
 	usageHeader            = `gocovsh: Go Coverage in your terminal
 
-Usage: %s [options]
+Usage: %s [command] [options]
 
-If provided, stdin is expected to be a list of files to be processed, for example:
+If provided, stdin is expected to be a diff or a list of files to be processed,
+for example:
 
 	git diff --name-only | %s
 
+The output of "git diff", with or without "--name-status" and "-z" flags, is
+supported, as well as other unified diffs.
+
+Use "--report text" to print a summary instead of starting the interactive
+viewer, for example in CI. Use "--format json" to export the coverage data.
+Use "--format markdown" with a diff on stdin to report the patch coverage.
+
+Options can also be set in .gocovsh.yaml file in the code root, in the user
+configuration file, or using GOCOVSH_<OPTION> environment variables, for
+example GOCOVSH_SORT_BY_COVERAGE=true. Use "config" command to print them.
+
+`
+	usageCommands = `Commands:
+
+`
+	usageOptions = `
 Supported options:
 
 `
@@ -35,10 +55,11 @@ Supported options:
 // `With...` functions.
 func New(opts ...Option) *Program {
 	p := &Program{
-		input:   os.Stdin,
-		output:  os.Stdout,
-		flagSet: flag.CommandLine,
-		args:    os.Args[1:],
+		input:     os.Stdin,
+		output:    os.Stdout,
+		errOutput: os.Stderr,
+		flagSet:   flag.CommandLine,
+		args:      os.Args[1:],
 	}
 
 	for _, opt := range opts {
@@ -47,13 +68,81 @@ func New(opts ...Option) *Program {
 
 	p.flagSet.BoolVar(&p.showVersion, "version", false, "show version")
 	p.flagSet.BoolVar(&p.sortByCoverage, "sort-by-coverage", false, "sort files by coverage instead of alphabetically")
+	p.flagSet.BoolVar(
+		&p.treeView, "tree", false,
+		"group the files by directories with their aggregated coverage; press t to switch the view",
+	)
+	p.flagSet.BoolVar(
+		&p.watch, "watch", false,
+		"reload the coverage data and the open file when they change, for example when tests run in another terminal",
+	)
+	p.flagSet.Var(
+		&p.profileFilenames, "profile",
+		"File name of coverage profile generated by go test -coverprofile coverage.out.\n"+
+			"Repeat the flag or use glob patterns to merge several profiles (default \""+defaultProfileFilename+"\")",
+	)
+	p.flagSet.Var(
+		&p.coverDirs, "coverdir",
+		"Directory with binary coverage data written by binaries built with go build -cover (GOCOVERDIR).\n"+
+			"Repeat the flag to merge several directories",
+	)
+	p.flagSet.Var(
+		&p.includePatterns, "include",
+		"only show the files matching the glob pattern, for example 'internal/**'.\n"+
+			"Patterns follow .gitignore rules; repeat the flag for several patterns",
+	)
+	p.flagSet.Var(
+		&p.excludePatterns, "exclude",
+		"hide the files matching the glob pattern, for example '*.pb.go' or 'cmd/'.\n"+
+			"Patterns follow .gitignore rules; repeat the flag for several patterns",
+	)
+	p.flagSet.BoolVar(
+		&p.showGenerated, "show-generated", false,
+		`show the files with "Code generated ... DO NOT EDIT." comment, hidden by default`,
+	)
+	p.flagSet.StringVar(
+		&p.codeRoot, "root", "",
+		"root directory of the Go module or workspace; by default, the closest parent directory\n"+
+			"with go.work or go.mod file. Relative profile paths are resolved against it",
+	)
+	p.flagSet.StringVar(
+		&p.diffFile, "diff", "",
+		"read the diff from the given patch file instead of stdin, for example the output of git format-patch;\n"+
+			"the line numbers come from the new revision of every hunk, so in a patch with many\n"+
+			"commits they refer to the file after the commit of that hunk",
+	)
+	p.flagSet.StringVar(
+		&p.diffBase, "diff-base", "",
+		"show the changes since the merge-base of the given git ref and HEAD, instead of reading a diff from stdin",
+	)
+	p.flagSet.BoolVar(
+		&p.staged, "staged", false,
+		"show the staged changes; combined with --diff-base, the staged changes since the merge-base",
+	)
+	p.flagSet.StringVar(
+		&p.reportFormat, "report", "",
+		"print a non-interactive report in the given format and exit; supported formats: "+supportedFormats(),
+	)
+	p.flagSet.StringVar(&p.reportFormat, "format", "", `alias for "--report"`)
+	p.flagSet.StringVar(
+		&p.annotationLevel, "annotation-level", string(report.AnnotationWarning),
+		"severity of the annotations report: notice, warning or error",
+	)
 	p.flagSet.StringVar(
-		&p.profileFilename, "profile", defaultProfileFilename,
-		"File name of coverage profile generated by go test -coverprofile coverage.out",
+		&p.lcovFilename, "lcov", "",
+		"also write the coverage to the given file in LCOV format, for example lcov.info",
 	)
+	p.flagSet.StringVar(&p.theme, "theme", "", "color theme: default, latte, frappe, macchiato or mocha")
 
 	p.flagSet.Usage = func() {
 		fmt.Fprintf(p.output, usageHeader, p.flagSet.Name(), p.flagSet.Name())
+		fmt.Fprint(p.output, usageCommands)
+
+		for _, cmd := range commands {
+			fmt.Fprintf(p.output, "\t%-8s %s\n", cmd.name, cmd.description)
+		}
+
+		fmt.Fprint(p.output, usageOptions)
 		p.flagSet.PrintDefaults()
 	}
 
@@ -69,22 +158,56 @@ type Program struct {
 	modVersion string
 	modSum     string
 
-	showVersion     bool
-	profileFilename string
-	sortByCoverage  bool
-
-	flagSet *flag.FlagSet
-	args    []string
-	input   fs.File
-	output  io.Writer
-	logFile string
+	showVersion      bool
+	profileFilenames stringsFlag
+	coverDirs        stringsFlag
+	includePatterns  stringsFlag
+	excludePatterns  stringsFlag
+	showGenerated    bool
+	sortByCoverage   bool
+	treeView         bool
+	watch            bool
+	codeRoot         string
+	subdir           string
+	diffFile         string
+	diffBase         string
+	staged           bool
+	reportFormat     string
+	lcovFilename     string
+	annotationLevel  string
+	theme            string
+	command          *command
+	outputDir        string
+	testExitCode     int
+	thresholds       report.Thresholds
+
+	flagSet       *flag.FlagSet
+	args          []string
+	input         fs.File
+	output        io.Writer
+	errOutput     io.Writer
+	logFile       string
+	userConfigDir string
+	config        *config
 
 	requestedFiles []string
 	diffLines      map[string][]int
+	inputErr       error
 }
 
 // Run parses the command line arguments and runs the program.
 func (p *Program) Run() error {
+	if len(p.args) > 0 {
+		if cmd := findCommand(p.args[0]); cmd != nil {
+			p.command = cmd
+			p.args = p.args[1:]
+
+			if cmd.flags != nil {
+				cmd.flags(p)
+			}
+		}
+	}
+
 	if err := p.flagSet.Parse(p.args); err != nil {
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
@@ -106,16 +229,64 @@ func (p *Program) Run() error {
 		return err
 	}
 
+	if err := p.loadConfig(); err != nil {
+		return err
+	}
+
+	if err := p.useTheme(); err != nil {
+		return err
+	}
+
+	if p.command != nil && p.command.standalone != nil {
+		return p.command.standalone(p)
+	}
+
+	for _, warning := range p.config.warnings {
+		fmt.Fprintf(p.errOutput, "warning: %s\n", warning)
+	}
+
+	if p.command != nil && p.command.prepare != nil {
+		cleanup, err := p.command.prepare(p)
+		if err != nil {
+			return err
+		}
+
+		defer cleanup()
+	}
+
+	if len(p.profileFilenames) == 0 && len(p.coverDirs) == 0 {
+		p.profileFilenames = stringsFlag{defaultProfileFilename}
+	}
+
+	if err := p.resolveCodeRoot(); err != nil {
+		return fmt.Errorf("failed to find code root: %w", err)
+	}
+
 	if err := p.parseInput(); err != nil {
 		return fmt.Errorf("failed to parse input: %w", err)
 	}
 
-	m := model.New(
-		model.WithProfileFilename(p.profileFilename),
+	opts := []model.Option{
+		model.WithCodeRoot(p.codeRoot),
+		model.WithSubdirectory(p.subdir),
+		model.WithProfileFilenames(p.profileFilenames...),
+		model.WithCoverDirs(p.coverDirs...),
 		model.WithRequestedFiles(p.requestedFiles),
+		model.WithIncludePatterns(p.includePatterns...),
+		model.WithExcludePatterns(p.excludePatterns...),
+		model.WithGeneratedFiles(p.showGenerated),
 		model.WithCoverageSorting(p.sortByCoverage),
+		model.WithTreeView(p.treeView),
 		model.WithFilteredLines(p.diffLines),
-	)
+		model.WithInputError(p.inputErr),
+		model.WithKeyBindings(p.config.keys),
+	}
+
+	if p.watch {
+		opts = append(opts, model.WithWatch(watchInterval))
+	}
+
+	m := model.New(opts...)
 
 	if p.logFile != "" {
 		f, err := tea.LogToFile(p.logFile, "gocovsh")
@@ -130,6 +301,44 @@ func (p *Program) Run() error {
 		log.SetOutput(io.Discard)
 	}
 
+	if p.lcovFilename != "" {
+		if err := p.writeLCOVFile(m); err != nil {
+			return err
+		}
+	}
+
+	if p.command != nil {
+		return p.command.run(p, m)
+	}
+
+	return p.show(m)
+}
+
+// useTheme selects the color theme. An unknown theme in the environment falls
+// back to the default one, as it did before the configuration files were
+// supported, while the flags and the files must name a known theme.
+func (p *Program) useTheme() error {
+	err := styles.UseTheme(p.theme)
+	if err == nil {
+		return nil
+	}
+
+	source := p.config.sources["theme"]
+	if !strings.HasPrefix(source, "env ") {
+		return err
+	}
+
+	p.config.warnings = append(p.config.warnings, fmt.Sprintf("%s: %v; using the default theme", source, err))
+
+	return styles.UseTheme("default")
+}
+
+// show writes the requested report, or starts the interactive viewer.
+func (p *Program) show(m *model.Model) error {
+	if p.reportFormat != "" {
+		return p.writeReport(m)
+	}
+
 	if err := tea.NewProgram(m, tea.WithAltScreen()).Start(); err != nil {
 		return fmt.Errorf("failed to start program: %w", err)
 	}
@@ -137,29 +346,134 @@ func (p *Program) Run() error {
 	return nil
 }
 
+func (p *Program) writeReport(m *model.Model) error {
+	c, err := p.loadCoverage(m)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(
+		p.output, report.Format(p.reportFormat), c,
+		report.WithAnnotationLevel(report.AnnotationLevel(p.annotationLevel)),
+	); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Program) writeLCOVFile(m *model.Model) error {
+	c, err := p.loadCoverage(m)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(p.lcovFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create LCOV file: %w", err)
+	}
+
+	if err := report.LCOV(f, c); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write LCOV file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write LCOV file: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Program) loadCoverage(m *model.Model) (*report.Coverage, error) {
+	profiles, err := m.LoadProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	return &report.Coverage{
+		ModuleName:   m.ModuleName(),
+		Modules:      m.Modules(),
+		CodeRoot:     m.CodeRoot(),
+		Sources:      m.SourceFiles(),
+		Profiles:     profiles,
+		ChangedLines: p.diffLines,
+	}, nil
+}
+
+func supportedFormats() string {
+	formats := make([]string, len(report.Formats))
+
+	for i, f := range report.Formats {
+		formats[i] = string(f)
+	}
+
+	return strings.Join(formats, ", ")
+}
+
+// parseInput reads the changes from the diff file, from git or from stdin.
+// Malformed input is reported by the model, so that it is displayed in the
+// same way as other errors.
 func (p *Program) parseInput() error {
-	if p.isInputStreamAvailable() {
+	var input string
+
+	switch {
+	case p.diffFile != "" && (p.diffBase != "" || p.staged):
+		return fmt.Errorf(`"--diff" can't be combined with "--diff-base" or "--staged"`)
+
+	case p.diffFile != "":
+		bs, err := os.ReadFile(p.diffFile) // nolint: gosec
+		if err != nil {
+			return fmt.Errorf("failed to read diff: %w", err)
+		}
+
+		input = string(bs)
+
+	case p.diffBase != "" || p.staged:
+		// the diff computed by git replaces stdin
+		diff, err := p.gitDiff()
+		if err != nil {
+			return fmt.Errorf("failed to compute diff: %w", err)
+		}
+
+		input = diff
+
+	case p.isInputStreamAvailable():
 		bs, err := io.ReadAll(p.input)
 		if err != nil {
 			return fmt.Errorf("failed to read stdin: %w", err)
 		}
 
-		if inputStr := strings.TrimSpace(string(bs)); strings.HasPrefix(inputStr, "diff ") {
-			if diff, err := diffparser.Parse(inputStr); err == nil {
-				p.diffLines = diff.Changed()
+		input = string(bs)
+	}
+
+	if strings.TrimSpace(input) == "" {
+		// an empty diff from git or from a file has no changes, while empty
+		// stdin is ignored
+		if p.diffFile != "" || p.diffBase != "" || p.staged {
+			p.requestedFiles = []string{}
+			p.diffLines = map[string][]int{}
+		}
+
+		return nil
+	}
+
+	c, err := parseChanges(input)
+	if err != nil {
+		p.inputErr = err
+		return nil
+	}
+
+	p.requestedFiles = c.files
 
-				for file := range p.diffLines {
-					if !strings.HasSuffix(file, ".go") {
-						delete(p.diffLines, file)
-					}
-				}
+	// only the changes in Go files are kept
+	if c.lines != nil {
+		p.diffLines = make(map[string][]int, len(c.lines))
 
-				for _, file := range diff.Files {
-					p.requestedFiles = append(p.requestedFiles, file.NewName)
-				}
+		for file, lines := range c.lines {
+			if strings.HasSuffix(file, ".go") {
+				p.diffLines[file] = lines
 			}
-		} else {
-			p.requestedFiles = p.splitLines(inputStr)
 		}
 	}
 
@@ -174,7 +488,3 @@ func (p *Program) isInputStreamAvailable() bool {
 
 	return fi.Mode()&os.ModeNamedPipe != 0
 }
-
-func (p *Program) splitLines(s string) []string {
-	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
-}
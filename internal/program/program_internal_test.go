@@ -3,6 +3,7 @@ package program
 import (
 	"flag"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/orlangure/gocovsh/internal/gocovshtest/input"
@@ -16,6 +17,130 @@ bar.go
 baz.go
 `
 
+	renameDiff = `diff --git a/a.go b/a.go
+index 2567407..e226f07 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,5 @@
+ package a
+ 
+-func A() {}
++func A() {
++	println()
++}
+diff --git a/c.go "b/new\tfile.go"
+similarity index 100%
+rename from c.go
+rename to "new\tfile.go"
+diff --git a/b.go b/renamed.go
+similarity index 84%
+rename from b.go
+rename to renamed.go
+index bc17470..580e064 100644
+--- a/b.go
++++ b/renamed.go
+@@ -1,5 +1,6 @@
+ package a
+ 
+ func B() int {
++	_ = 2
+ 	return 1
+ }
+diff --git a/d.go b/d.go
+deleted file mode 100644
+index 2a93cde..0000000
+--- a/d.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package a
+`
+
+	noPrefixDiff = `diff --git a.go a.go
+index 2567407..e226f07 100644
+--- a.go
++++ a.go
+@@ -1,3 +1,5 @@
+ package a
+ 
+-func A() {}
++func A() {
++	println()
++}
+`
+
+	combinedDiff = `diff --cc a.go
+index e226f07,82b5f82..c853bf1
+--- a/a.go
++++ b/a.go
+@@@ -1,5 -1,5 +1,6 @@@
+  package a
+  
+  func A() {
+ +	println()
++ 	print()
+  }
+diff --cc patch.diff
+index 0000000,0000000..84c1bf4
+new file mode 100644
+--- /dev/null
++++ b/patch.diff
+@@@ -1,0 -1,0 +1,4 @@@
+++--- a/a.go
++++++ b/a.go
+++@@ -1,3 +1,5 @@
+++-func A() {}
+`
+
+	patchFile = `From 2b5e0c2 Mon Sep 17 00:00:00 2001
+From: Gopher <gopher@example.com>
+Subject: [PATCH] Change a
+
+---
+ a.go | 4 +++-
+ 1 file changed, 3 insertions(+), 1 deletion(-)
+
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,5 @@
+ package a
+ 
+-func A() {}
++func A() {
++	println()
++}
+--- a/b.go	2024-01-01 10:00:00
++++ b/b.go	2024-01-02 10:00:00
+@@ -3 +3 @@
+--- removed
++++ added
+-- 
+2.39.0
+`
+
+	multiCommitPatch = `From 5c1e8f0 Mon Sep 17 00:00:00 2001
+From: Gopher <gopher@example.com>
+Subject: [PATCH] Document a
+
+---
+ a.go | 2 ++
+ 1 file changed, 2 insertions(+)
+
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,3 @@
++// Package a is an example.
+ package a
+ 
+@@ -3,2 +4,3 @@
+ func A() {
++	println()
+ 	println()
+-- 
+2.39.0
+`
+
+	nameStatus = "M\ta.go\nR084\tb.go\trenamed.go\nD\tc.go\nA\t\"new\\tfile.go\"\n"
+
 	diffStr = `
 diff --git a/main.go b/main.go
 index e6cd709..adc400e 100644
@@ -63,3 +188,110 @@ func TestParseInput(t *testing.T) {
 		require.EqualValues(t, []string{"main.go"}, p.requestedFiles)
 	})
 }
+
+func TestParseChanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		files []string
+		lines map[string][]int
+	}{
+		{
+			name:  "renames, copies and deletions",
+			input: renameDiff,
+			files: []string{"a.go", "new\tfile.go", "renamed.go"},
+			lines: map[string][]int{"a.go": {3, 4, 5}, "renamed.go": {4}},
+		},
+		{
+			name:  "no prefix",
+			input: noPrefixDiff,
+			files: []string{"a.go"},
+			lines: map[string][]int{"a.go": {3, 4, 5}},
+		},
+		{
+			name:  "combined",
+			input: combinedDiff,
+			files: []string{"a.go", "patch.diff"},
+			lines: map[string][]int{"a.go": {4, 5}, "patch.diff": {1, 2, 3, 4}},
+		},
+		{
+			name:  "patch file",
+			input: patchFile,
+			files: []string{"a.go", "b.go"},
+			lines: map[string][]int{"a.go": {3, 4, 5}, "b.go": {3}},
+		},
+		{
+			name:  "patch with many commits",
+			input: patchFile + multiCommitPatch,
+			files: []string{"a.go", "b.go"},
+			lines: map[string][]int{"a.go": {1, 3, 4, 5}, "b.go": {3}},
+		},
+		{
+			name:  "name status",
+			input: nameStatus,
+			files: []string{"a.go", "renamed.go", "new\tfile.go"},
+		},
+		{
+			name:  "NUL separated names",
+			input: "a.go\x00new file.go\x00",
+			files: []string{"a.go", "new file.go"},
+		},
+		{
+			name:  "NUL separated name status",
+			input: "M\x00a.go\x00R084\x00b.go\x00renamed.go\x00D\x00c.go\x00",
+			files: []string{"a.go", "renamed.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := parseChanges(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.files, c.files)
+
+			if tt.lines != nil {
+				require.Equal(t, tt.lines, c.lines)
+			} else {
+				require.Nil(t, c.lines)
+			}
+		})
+	}
+
+	t.Run("invalid hunk header", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseChanges("--- a/a.go\n+++ b/a.go\n@@ -1 +x @@\n")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid hunk header")
+	})
+
+	t.Run("truncated hunk", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseChanges(strings.Join(strings.Split(noPrefixDiff, "\n")[:8], "\n"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hunk is shorter than its header says")
+	})
+
+	t.Run("hunk without file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseChanges("@@ -1 +1 @@\n-a\n+b\ndiff --git a/a.go b/a.go\n")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hunk without a file header")
+	})
+
+	t.Run("missing file names", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseChanges("diff -u\n@@ -1 +1 @@\n-a\n+b\n")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "diff without file names")
+	})
+}
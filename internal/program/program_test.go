@@ -2,10 +2,16 @@ package program_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,3 +95,930 @@ func TestInput(t *testing.T) {
 		require.False(t, f.InputRead)
 	})
 }
+
+func TestTextReport(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	t.Run("all files", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--profile", "profile.cover", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "covered.go")
+		requireTotal(t, buf.String(), "Total 5 4 80.00%")
+	})
+
+	t.Run("sorted by coverage", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{
+				"--profile", "profile.cover", "--report", "text", "--sort-by-coverage",
+			}),
+		)
+
+		require.NoError(t, p.Run())
+
+		lines := strings.Split(buf.String(), "\n")
+		require.True(t, strings.HasPrefix(lines[1], "partial_"))
+		require.True(t, strings.HasPrefix(lines[2], "covered.go"))
+	})
+
+	t.Run("requested files", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := input.NewMockFile("covered.go\n", os.ModeNamedPipe)
+		p := program.New(
+			program.WithInput(f),
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--profile", "profile.cover", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.NotContains(t, buf.String(), "partial_")
+		requireTotal(t, buf.String(), "Total 1 1 100.00%")
+	})
+
+	t.Run("merged profiles", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--profile", "profiles/*.cover", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		requireTotal(t, buf.String(), "Total 5 5 100.00%")
+	})
+
+	t.Run("summed counts", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{
+				"--profile", "count.cover", "--profile", "count.cover", "--format", "json",
+			}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), `"count": 6`)
+	})
+
+	t.Run("conflicting modes", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{
+				"--profile", "profile.cover", "--profile", "count.cover", "--report", "text",
+			}),
+		)
+
+		err := p.Run()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `count.cover uses "count" mode, expected "set"`)
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--profile", "missing.cover", "--report", "text"}),
+		)
+
+		require.Error(t, p.Run())
+		require.Empty(t, buf.String())
+	})
+}
+
+func TestModules(t *testing.T) {
+	t.Run("nested modules", func(t *testing.T) {
+		chdir(t, "../gocovshtest/testdata/nested")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+
+		lines := strings.Split(buf.String(), "\n")
+		require.True(t, strings.HasPrefix(lines[1], "main.go"))
+		require.True(t, strings.HasPrefix(lines[2], "plugin/plugin.go"))
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		chdir(t, "../gocovshtest/testdata/workspace")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--report", "cobertura"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), `<package name="example.com/api"`)
+		require.Contains(t, buf.String(), `<package name="example.com/tools/gen"`)
+		require.Contains(t, buf.String(), `filename="tools/gen/gen.go"`)
+	})
+}
+
+func TestPatterns(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/generated")
+
+	t.Run("generated files are hidden", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--report", "text", "--exclude", "cmd/"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "service.go")
+		require.NotContains(t, buf.String(), "main.go")
+		require.NotContains(t, buf.String(), "api.pb.go")
+	})
+
+	t.Run("generated files are shown", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--report", "text", "--include", "api/**", "--show-generated"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "api.pb.go")
+		require.NotContains(t, buf.String(), "service.go")
+	})
+}
+
+func TestConfig(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/config")
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, args),
+			program.WithUserConfigDir("user"),
+		)
+
+		err := p.Run()
+
+		return buf.String(), err
+	}
+
+	t.Run("project file takes precedence over user file", func(t *testing.T) {
+		out, err := run(t, "--report", "text")
+		require.NoError(t, err)
+		require.Contains(t, out, "service.go")
+		require.NotContains(t, out, "main.go")
+	})
+
+	t.Run("env takes precedence over files", func(t *testing.T) {
+		t.Setenv("GOCOVSH_EXCLUDE", "service.go,other.go")
+
+		out, err := run(t, "--report", "text")
+		require.NoError(t, err)
+		require.Contains(t, out, "main.go")
+		require.NotContains(t, out, "service.go")
+	})
+
+	t.Run("flags take precedence over env", func(t *testing.T) {
+		t.Setenv("GOCOVSH_EXCLUDE", "service.go")
+
+		out, err := run(t, "--report", "text", "--exclude", "cmd/")
+		require.NoError(t, err)
+		require.Contains(t, out, "service.go")
+		require.NotContains(t, out, "main.go")
+	})
+
+	t.Run("config command", func(t *testing.T) {
+		t.Setenv("GOCOVSH_ANNOTATION_LEVEL", "error")
+
+		out, err := run(t, "config", "--watch")
+		require.NoError(t, err)
+		require.Regexp(t, `project\s+\S+\.gocovsh\.yaml\s*\n`, out)
+		require.Regexp(t, `user\s+user/config\.yaml\s*\n`, out)
+		require.Regexp(t, `profile\s+cover\.out\s+project file \S+\.gocovsh\.yaml\n`, out)
+		require.Regexp(t, `sort-by-coverage\s+true\s+user file user/config\.yaml\n`, out)
+		require.Regexp(t, `min-total\s+90\s+user file user/config\.yaml\n`, out)
+		require.Regexp(t, `annotation-level\s+error\s+env GOCOVSH_ANNOTATION_LEVEL\n`, out)
+		require.Regexp(t, `watch\s+true\s+flag\n`, out)
+		require.Regexp(t, `include\s+default\n`, out)
+		require.Regexp(t, `keys\.open\s+enter, o\s+project file`, out)
+		require.Regexp(t, `keys\.quit\s+Q\s+user file`, out)
+		require.Regexp(t, `keys\.back\s+esc\s+default`, out)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for _, tt := range []struct{ config, err string }{
+			{"unknown: true", `unknown setting "unknown"`},
+			{"root: /tmp", `unknown setting "root"`},
+			{"theme: [a, b]", `"theme" expects a single value`},
+			{"show-generated: maybe", `invalid value "maybe"`},
+			{"keys:\n  jump: j", `unknown key action "jump"`},
+			{"keys:\n  toggle-sort: q", `key "q" is bound to both "quit" and "toggle-sort"`},
+			{"keys:\n  help: [H, x]", `key "x" is bound to both "help" and "toggle-generated"`},
+			{"keys:\n  toggle-tree: /", `key "/" is bound to both "toggle-tree" and "filter" of the list`},
+			{"keys:\n  back: d", `key "d" is bound to both "back" and "next page" of the list`},
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(tt.config), 0o600))
+
+			p := program.New(
+				program.WithOutput(bytes.NewBuffer(nil)),
+				program.WithFlagSet(flag.NewFlagSet("test", flag.ContinueOnError), nil),
+				program.WithUserConfigDir(dir),
+			)
+
+			err := p.Run()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		}
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		_, err := run(t, "--report", "text", "--theme", "neon")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown theme "neon"`)
+	})
+
+	t.Run("unknown theme in the environment", func(t *testing.T) {
+		t.Setenv("GOCOVSH_THEME", "neon")
+
+		errBuf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(io.Discard),
+			program.WithErrorOutput(errBuf),
+			program.WithFlagSet(flagSet, []string{"--report", "text"}),
+			program.WithUserConfigDir("user"),
+		)
+
+		require.NoError(t, p.Run())
+		require.Equal(
+			t,
+			"warning: env GOCOVSH_THEME: unknown theme \"neon\", use default, latte, frappe, macchiato or mocha; "+
+				"using the default theme\n",
+			errBuf.String(),
+		)
+
+		out, err := run(t, "config")
+		require.NoError(t, err)
+		require.Contains(t, out, "Warnings:")
+		require.Contains(t, out, `env GOCOVSH_THEME: unknown theme "neon"`)
+	})
+
+	t.Run("unknown theme in the project file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/theme\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gocovsh.yaml"), []byte("theme: neon\n"), 0o600))
+
+		_, err := run(t, "--root", dir, "--report", "text")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown theme "neon"`)
+	})
+}
+
+func TestDoctorCommand(t *testing.T) {
+	chdir(t, copyTestdata(t, "../gocovshtest/testdata/doctor"))
+
+	// the checkout order must not make the sources look modified
+	modTime := time.Date(2022, 1, 29, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"coverage.out", "ok.go", "line.go", "short.go", "stale.go"} {
+		require.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+
+	require.NoError(t, os.Chtimes("stale.go", modTime, modTime.Add(time.Hour)))
+
+	writeFile(t, "ok.out", "mode: set\nexample.com/doctor/ok.go:4.24,6.2 1 1\n")
+	require.NoError(t, os.Chtimes("ok.out", modTime, modTime))
+
+	t.Run("problems found", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"doctor"}),
+		)
+
+		err := p.Run()
+
+		var exitErr *program.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 1, exitErr.Code)
+
+		out := buf.String()
+		require.Contains(t, out, "Checked 6 files, found problems in 5:")
+		require.Contains(t, out, "\ngone.go: missing file\n\tgone.go does not exist\n")
+		require.Contains(t, out, "\nline.go: invalid block\n\tblock 4.17,6.20 points past the end of line 6\n")
+		require.Contains(t, out, "\nshort.go: invalid block\n\tblock 4.14,12.2 points past the end of the file with 4 lines\n")
+		require.Contains(t, out, "\nstale.go: modified source\n\tthe file was modified at ")
+		require.Contains(t, out, "\nexample.org/other/other.go: unknown module\n")
+		require.Contains(t, out, "\tfix: run the tests again")
+		require.NotContains(t, out, "ok.go")
+	})
+
+	t.Run("no problems", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"doctor", "--profile", "ok.out"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Equal(t, "Checked 1 file, no problems found\n", buf.String())
+	})
+
+	t.Run("filtered files are checked", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"doctor", "--include", "ok.go", "--exclude", "*.go"}),
+		)
+
+		var exitErr *program.ExitError
+		require.ErrorAs(t, p.Run(), &exitErr)
+		require.Contains(t, buf.String(), "Checked 6 files, found problems in 5:")
+	})
+}
+
+func TestCodeRoot(t *testing.T) {
+	t.Run("workspace subdirectory", func(t *testing.T) {
+		chdir(t, "../gocovshtest/testdata/workspace/tools/gen")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "tools/gen/gen.go")
+		require.NotContains(t, buf.String(), "api.go")
+	})
+
+	t.Run("module subdirectory without files", func(t *testing.T) {
+		chdir(t, "../gocovshtest/testdata/general/profiles")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--profile", "profile.cover", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		requireTotal(t, buf.String(), "Total 5 4 80.00%")
+	})
+
+	t.Run("root flag", func(t *testing.T) {
+		chdir(t, "../gocovshtest/testdata/nested/plugin")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--root", "..", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "plugin/plugin.go")
+		require.NotContains(t, buf.String(), "main.go")
+	})
+}
+
+func TestCoverDir(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	t.Run("single directory", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--coverdir", "covdata/unit", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "covered.go")
+		requireTotal(t, buf.String(), "Total 5 1 20.00%")
+	})
+
+	t.Run("merged directories", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{
+				"--coverdir", "covdata/unit", "--coverdir", "covdata/e2e", "--report", "text",
+			}),
+		)
+
+		require.NoError(t, p.Run())
+		requireTotal(t, buf.String(), "Total 5 4 80.00%")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--coverdir", "covdata/missing", "--report", "text"}),
+		)
+
+		require.Error(t, p.Run())
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "covmeta.0123"), []byte("invalid"), 0o600))
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--coverdir", dir, "--report", "text"}),
+		)
+
+		err := p.Run()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not a coverage meta-data file")
+	})
+
+	t.Run("corrupted data", func(t *testing.T) {
+		// the string table of the package claims to hold 2^63-1 strings
+		hugeStrings := make([]byte, 44)
+		hugeStrings = append(hugeStrings, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+
+		for name, data := range map[string][]byte{
+			// the size of the package table overflows when multiplied
+			"package table": coverMetaFile(1<<60, nil),
+			"string table":  coverMetaFile(1, hugeStrings),
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "covmeta.0123"), data, 0o600))
+
+				flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+				p := program.New(
+					program.WithOutput(bytes.NewBuffer(nil)),
+					program.WithFlagSet(flagSet, []string{"--coverdir", dir, "--report", "text"}),
+				)
+
+				require.NotPanics(t, func() { require.Error(t, p.Run()) })
+			})
+		}
+	})
+}
+
+// coverMetaFile builds a binary coverage meta-data file with the number of
+// packages written in its header, and a single package.
+func coverMetaFile(entries uint64, pkg []byte) []byte {
+	le := binary.LittleEndian
+	data := make([]byte, 56)
+	copy(data, []byte{0, 'c', 'v', 'm'})
+	le.PutUint64(data[16:], entries)
+	data[48] = 1 // set mode
+
+	table := make([]byte, 16)
+	le.PutUint64(table, uint64(len(data)+len(table)))
+	le.PutUint64(table[8:], uint64(len(pkg)))
+
+	return append(append(data, table...), pkg...)
+}
+
+func TestRunCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	chdir(t, "../gocovshtest/testdata/general")
+
+	t.Run("passing tests", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		errBuf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithErrorOutput(errBuf),
+			program.WithFlagSet(flagSet, []string{"run", "--report", "text", ".", "-count", "1"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, errBuf.String(), "ok ")
+		require.NotContains(t, buf.String(), "ok ")
+		requireTotal(t, buf.String(), "Total 5 4 80.00%")
+	})
+
+	t.Run("json report", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithErrorOutput(io.Discard),
+			program.WithFlagSet(flagSet, []string{"run", "--format", "json", ".", "-count", "1"}),
+		)
+
+		require.NoError(t, p.Run())
+
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	})
+
+	t.Run("failing packages", func(t *testing.T) {
+		errBuf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(io.Discard),
+			program.WithErrorOutput(errBuf),
+			program.WithFlagSet(flagSet, []string{"run", "--report", "text", "./missing"}),
+		)
+
+		err := p.Run()
+		require.Error(t, err)
+
+		var exitErr *program.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 1, exitErr.Code)
+		require.Contains(t, errBuf.String(), "Failing packages:")
+	})
+}
+
+func TestGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	writeFile(t, "go.mod", "module example.com/diff\n")
+	writeFile(t, "foo.go", "package diff\n\nfunc Foo() int {\n\treturn 1\n}\n")
+	writeFile(t, "bar.go", "package diff\n\nfunc Bar() int {\n\treturn 2\n}\n")
+	writeFile(t, "coverage.out", "mode: set\n"+
+		"example.com/diff/foo.go:3.16,6.2 2 1\n"+
+		"example.com/diff/bar.go:3.16,6.2 2 0\n",
+	)
+
+	runGit(t, "init", "-q")
+	runGit(t, "checkout", "-q", "-b", "main")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "initial")
+	runGit(t, "checkout", "-q", "-b", "feature")
+
+	writeFile(t, "foo.go", "package diff\n\nfunc Foo() int {\n\t_ = 0\n\treturn 1\n}\n")
+	runGit(t, "commit", "-q", "-am", "change foo")
+
+	writeFile(t, "bar.go", "package diff\n\nfunc Bar() int {\n\t_ = 0\n\treturn 2\n}\n")
+	runGit(t, "add", "bar.go")
+
+	t.Run("diff base", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--diff-base", "main", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "foo.go")
+		require.Contains(t, buf.String(), "bar.go")
+	})
+
+	t.Run("staged", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--staged", "--report", "json"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.NotContains(t, buf.String(), "foo.go")
+		require.Contains(t, buf.String(), "bar.go")
+		require.Contains(t, buf.String(), `"changedLines": [`)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		chdir(t, t.TempDir())
+
+		writeFile(t, "go.mod", "module example.com/diff\n")
+		writeFile(t, "foo.go", "package diff\n\nfunc Foo() int {\n\treturn 1\n}\n")
+		writeFile(t, "coverage.out", "mode: set\nexample.com/diff/foo.go:3.16,5.2 1 0\n")
+
+		runGit(t, "init", "-q")
+		runGit(t, "add", ".")
+		runGit(t, "commit", "-q", "-m", "initial")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"check", "--diff-base", "HEAD", "--min-patch", "80"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Equal(t, "OK: all coverage thresholds are met\n", buf.String())
+
+		buf.Reset()
+		flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+		p = program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--staged", "--format", "markdown"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "No Go files were changed.")
+	})
+
+	t.Run("invalid base", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--diff-base", "missing", "--report", "text"}),
+		)
+
+		err := p.Run()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "merge-base missing HEAD")
+	})
+
+	t.Run("root in another repository", func(t *testing.T) {
+		chdir(t, t.TempDir())
+		runGit(t, "init", "-q")
+
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--root", dir, "--diff-base", "main", "--report", "text"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "foo.go")
+		require.Contains(t, buf.String(), "bar.go")
+	})
+}
+
+func TestDiffFile(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	writeFile(t, "go.mod", "module example.com/diff\n")
+	writeFile(t, "foo.go", "package diff\n\nfunc Foo() int {\n\t_ = 0\n\treturn 1\n}\n")
+	writeFile(t, "bar.go", "package diff\n\nfunc Bar() int {\n\treturn 2\n}\n")
+	writeFile(t, "coverage.out", "mode: set\n"+
+		"example.com/diff/foo.go:3.16,6.2 2 1\n"+
+		"example.com/diff/bar.go:3.16,5.2 1 0\n",
+	)
+	writeFile(t, "change.patch", "diff --git a/foo.go b/foo.go\n"+
+		"--- a/foo.go\n+++ b/foo.go\n"+
+		"@@ -3,2 +3,3 @@\n func Foo() int {\n+\t_ = 0\n \treturn 1\n"+
+		"diff --git a/baz.go b/bar.go\n"+
+		"similarity index 100%\nrename from baz.go\nrename to bar.go\n",
+	)
+	writeFile(t, "broken.patch", "--- a/foo.go\n+++ b/foo.go\n@@ -3,2 +3,3 @@\n func Foo() int {\n")
+
+	t.Run("patch file", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--diff", "change.patch", "--report", "json"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "foo.go")
+		require.Contains(t, buf.String(), "bar.go")
+		require.Contains(t, buf.String(), `"changedLines": [`)
+	})
+
+	t.Run("malformed patch", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--diff", "broken.patch", "--report", "text"}),
+		)
+
+		err := p.Run()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hunk is shorter than its header says")
+	})
+
+	t.Run("combined with staged", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"--diff", "change.patch", "--staged"}),
+		)
+
+		require.Error(t, p.Run())
+	})
+}
+
+func TestHTMLCommand(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	t.Run("output directory", func(t *testing.T) {
+		dir := t.TempDir()
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"html", "-o", dir, "--profile", "profile.cover"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.FileExists(t, filepath.Join(dir, "index.html"))
+		require.FileExists(t, filepath.Join(dir, "files", "covered.go.html"))
+	})
+
+	t.Run("missing output directory", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithFlagSet(flagSet, []string{"html", "--profile", "profile.cover"}),
+		)
+
+		require.Error(t, p.Run())
+	})
+}
+
+func TestLCOVFile(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	lcovFile := filepath.Join(t.TempDir(), "lcov.info")
+	buf := bytes.NewBuffer(nil)
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := program.New(
+		program.WithOutput(buf),
+		program.WithFlagSet(flagSet, []string{
+			"--profile", "profile.cover", "--lcov", lcovFile, "--report", "text",
+		}),
+	)
+
+	require.NoError(t, p.Run())
+	requireTotal(t, buf.String(), "Total 5 4 80.00%")
+
+	lcov, err := os.ReadFile(lcovFile)
+	require.NoError(t, err)
+	require.Contains(t, string(lcov), "SF:covered.go\nFN:3,Full\n")
+}
+
+func TestCheckCommand(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{name: "passing", args: []string{"--min-total", "80", "--min-file", "75"}},
+		{name: "total", args: []string{"--min-total", "90"}, exitCode: program.ExitCodeTotal},
+		{name: "file", args: []string{"--min-file", "90"}, exitCode: program.ExitCodeFile},
+		{
+			name:     "combined",
+			args:     []string{"--min-package", "90", "--min-file", "90"},
+			exitCode: program.ExitCodePackage | program.ExitCodeFile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			p := program.New(
+				program.WithOutput(buf),
+				program.WithFlagSet(flagSet, append([]string{"check", "--profile", "profile.cover"}, test.args...)),
+			)
+
+			err := p.Run()
+			if test.exitCode == 0 {
+				require.NoError(t, err)
+				require.Contains(t, buf.String(), "OK")
+
+				return
+			}
+
+			var exitErr *program.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			require.Equal(t, test.exitCode, exitErr.Code)
+			require.Contains(t, buf.String(), "FAIL")
+		})
+	}
+}
+
+func TestDiffWithoutGoFiles(t *testing.T) {
+	chdir(t, "../gocovshtest/testdata/general")
+
+	diff := "diff --git a/README.md b/README.md\n" +
+		"--- a/README.md\n+++ b/README.md\n" +
+		"@@ -1,1 +1,2 @@\n # general\n+more\n"
+
+	t.Run("patch check", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithInput(input.NewMockFile(diff, os.ModeNamedPipe)),
+			program.WithFlagSet(flagSet, []string{"check", "--profile", "profile.cover", "--min-patch", "80", "--min-total", "80"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Equal(t, "OK: all coverage thresholds are met\n", buf.String())
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		p := program.New(
+			program.WithOutput(buf),
+			program.WithInput(input.NewMockFile(diff, os.ModeNamedPipe)),
+			program.WithFlagSet(flagSet, []string{"--profile", "profile.cover", "--format", "markdown"}),
+		)
+
+		require.NoError(t, p.Run())
+		require.Contains(t, buf.String(), "No Go files were changed.")
+	})
+}
+
+func requireTotal(t *testing.T, output, expected string) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Equal(t, expected, strings.Join(strings.Fields(lines[len(lines)-1]), " "))
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+}
+
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// copyTestdata copies the files of the directory into a temporary one, so that
+// the tests can change them.
+func copyTestdata(t *testing.T, src string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		bs, err := os.ReadFile(filepath.Join(src, entry.Name())) // nolint: gosec
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, entry.Name()), bs, 0o600))
+	}
+
+	return dir
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+}
@@ -0,0 +1,74 @@
+package program
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveCodeRoot sets the code root when it is not set by the flags, and the
+// subdirectory of the code root the program is started from, if any.
+func (p *Program) resolveCodeRoot() error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if p.codeRoot == "" {
+		root, ok := findCodeRoot(wd)
+		if !ok {
+			// the model reports the missing go.mod file
+			p.codeRoot = "."
+			return nil
+		}
+
+		p.codeRoot = root
+	}
+
+	root, err := filepath.Abs(p.codeRoot)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(root, wd)
+	if err != nil {
+		return nil
+	}
+
+	if rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		p.subdir = filepath.ToSlash(rel)
+	}
+
+	// relative roots keep the file names short in the error messages
+	if rel, err := filepath.Rel(wd, root); err == nil {
+		p.codeRoot = rel
+	}
+
+	return nil
+}
+
+// findCodeRoot walks up from the directory to find the root of the code, the
+// same way the go command does: a go.work file takes precedence, otherwise
+// the closest go.mod file is used.
+func findCodeRoot(dir string) (string, bool) {
+	if root, ok := findUp(dir, "go.work"); ok {
+		return root, true
+	}
+
+	return findUp(dir, "go.mod")
+}
+
+func findUp(dir, name string) (string, bool) {
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, name)); err == nil && !fi.IsDir() {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
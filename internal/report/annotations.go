@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/orlangure/gocovsh/internal/model"
+)
+
+// AnnotationLevel is the severity of CI annotations.
+type AnnotationLevel string
+
+// Supported annotation levels.
+const (
+	AnnotationNotice  AnnotationLevel = "notice"
+	AnnotationWarning AnnotationLevel = "warning"
+	AnnotationError   AnnotationLevel = "error"
+)
+
+// Annotations renders a workflow command, such as "::warning file=...", for
+// every contiguous range of uncovered changed lines, followed by the summary
+// of the patch coverage. CI systems that understand these commands display
+// them inline in the diff. It requires a diff.
+func Annotations(w io.Writer, c *Coverage, level AnnotationLevel) error {
+	if c.ChangedLines == nil {
+		return errDiffRequired
+	}
+
+	switch level {
+	case AnnotationNotice, AnnotationWarning, AnnotationError:
+	default:
+		return fmt.Errorf("unsupported annotation level: %q", level)
+	}
+
+	var (
+		total, covered int64
+		sb             strings.Builder
+		profiles       = profilesByName(c.Profiles)
+	)
+
+	for _, file := range changedFiles(c) {
+		p, ok := profiles[file]
+		if !ok {
+			continue
+		}
+
+		changedLines := c.ChangedLines[file]
+		fileTotal, fileCovered := model.PatchStatements(p, changedLines)
+		total += fileTotal
+		covered += fileCovered
+
+		for _, r := range lineRanges(model.UncoveredLines(p, changedLines)) {
+			message := fmt.Sprintf("Line %d is not covered by tests", r.start)
+			if r.end > r.start {
+				message = fmt.Sprintf("Lines %d-%d are not covered by tests", r.start, r.end)
+			}
+
+			fmt.Fprintf(
+				&sb, "::%s file=%s,line=%d,endLine=%d,title=%s::%s\n",
+				level, escapeAnnotationProperty(file), r.start, r.end,
+				escapeAnnotationProperty("Uncovered lines"), escapeAnnotationData(message),
+			)
+		}
+	}
+
+	if total == 0 {
+		sb.WriteString("Patch coverage: n/a, the changes do not include any statements\n")
+	} else {
+		fmt.Fprintf(
+			&sb, "Patch coverage: %.2f%% (%d of %d changed statements are covered)\n",
+			model.Percent(covered, total), covered, total,
+		)
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+type lineRange struct {
+	start, end int
+}
+
+// lineRanges merges sorted line numbers into ranges of consecutive lines.
+func lineRanges(lines []int) []lineRange {
+	var ranges []lineRange
+
+	for _, line := range lines {
+		if n := len(ranges); n > 0 && ranges[n-1].end+1 == line {
+			ranges[n-1].end = line
+			continue
+		}
+
+		ranges = append(ranges, lineRange{start: line, end: line})
+	}
+
+	return ranges
+}
+
+var (
+	annotationDataReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	annotationPropReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeAnnotationData(s string) string {
+	return annotationDataReplacer.Replace(s)
+}
+
+func escapeAnnotationProperty(s string) string {
+	return annotationPropReplacer.Replace(s)
+}
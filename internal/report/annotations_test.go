@@ -0,0 +1,49 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotations(t *testing.T) {
+	c := testCoverage()
+	c.ChangedLines = map[string][]int{
+		"foo.go":     {4, 7, 8, 10},
+		"bar/bar.go": {4},
+	}
+
+	t.Run("default level", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, report.Write(buf, report.FormatAnnotations, c))
+
+		expected := "::warning file=foo.go,line=7,endLine=8,title=Uncovered lines::Lines 7-8 are not covered by tests\n" +
+			"::warning file=foo.go,line=10,endLine=10,title=Uncovered lines::Line 10 is not covered by tests\n" +
+			"Patch coverage: 50.00% (2 of 4 changed statements are covered)\n"
+		require.Equal(t, expected, buf.String())
+	})
+
+	t.Run("custom level", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, report.Write(
+			buf, report.FormatAnnotations, c,
+			report.WithAnnotationLevel(report.AnnotationError),
+		))
+		require.Contains(t, buf.String(), "::error file=foo.go,line=7,endLine=8,")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.Error(t, report.Write(
+			buf, report.FormatAnnotations, c,
+			report.WithAnnotationLevel(report.AnnotationLevel("debug")),
+		))
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("no diff", func(t *testing.T) {
+		require.Error(t, report.Write(bytes.NewBuffer(nil), report.FormatAnnotations, testCoverage()))
+	})
+}
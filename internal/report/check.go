@@ -0,0 +1,165 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/orlangure/gocovsh/internal/model"
+)
+
+// CheckCategory is the kind of a coverage threshold.
+type CheckCategory string
+
+// Supported threshold categories.
+const (
+	CheckTotal   CheckCategory = "total"
+	CheckPackage CheckCategory = "package"
+	CheckFile    CheckCategory = "file"
+	CheckPatch   CheckCategory = "patch"
+)
+
+// Thresholds are the minimum coverage percentages required by Check. Zero
+// values disable the corresponding checks.
+type Thresholds struct {
+	Total   float64
+	Package float64
+	File    float64
+	Patch   float64
+}
+
+// Violation is a single coverage value below its threshold.
+type Violation struct {
+	Category CheckCategory
+
+	// Name is the package or the file name, empty for total and patch
+	// coverage.
+	Name string
+
+	Actual   float64
+	Required float64
+}
+
+func (v Violation) String() string {
+	subject := fmt.Sprintf("%s coverage", v.Category)
+	if v.Name != "" {
+		subject = fmt.Sprintf("%s %s", v.Category, v.Name)
+	}
+
+	return fmt.Sprintf("FAIL %s: %.2f%% < %.2f%% required", subject, v.Actual, v.Required)
+}
+
+// Check compares the coverage with the thresholds, prints every violation
+// and returns them. Files, packages and totals without statements are not
+// checked, so a diff without changed Go files passes. Patch coverage requires
+// a diff.
+func Check(w io.Writer, c *Coverage, t Thresholds) ([]Violation, error) {
+	if t.Patch > 0 && c.ChangedLines == nil {
+		return nil, fmt.Errorf("patch coverage can't be checked: %w", errDiffRequired)
+	}
+
+	var (
+		violations     []Violation
+		total, covered int64
+		packages       = map[string]*statements{}
+	)
+
+	for _, p := range c.Profiles {
+		fileTotal, fileCovered := model.CountStatements(p)
+		total += fileTotal
+		covered += fileCovered
+
+		pkg := c.packagePath(p.FileName)
+		if _, ok := packages[pkg]; !ok {
+			packages[pkg] = &statements{}
+		}
+
+		packages[pkg].total += fileTotal
+		packages[pkg].covered += fileCovered
+
+		if fileTotal > 0 {
+			violations = checkThreshold(violations, CheckFile, p.FileName, fileCovered, fileTotal, t.File)
+		}
+	}
+
+	if total > 0 {
+		violations = checkThreshold(violations, CheckTotal, "", covered, total, t.Total)
+	}
+
+	pkgNames := make([]string, 0, len(packages))
+	for pkg := range packages {
+		pkgNames = append(pkgNames, pkg)
+	}
+
+	sort.Strings(pkgNames)
+
+	for _, pkg := range pkgNames {
+		if stmts := packages[pkg]; stmts.total > 0 {
+			violations = checkThreshold(violations, CheckPackage, pkg, stmts.covered, stmts.total, t.Package)
+		}
+	}
+
+	if t.Patch > 0 {
+		if patchTotal, patchCovered := patchStatements(c); patchTotal > 0 {
+			violations = checkThreshold(violations, CheckPatch, "", patchCovered, patchTotal, t.Patch)
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return checkOrder[violations[i].Category] < checkOrder[violations[j].Category]
+	})
+
+	for _, v := range violations {
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return nil, fmt.Errorf("failed to write violation: %w", err)
+		}
+	}
+
+	if len(violations) == 0 {
+		if _, err := fmt.Fprintln(w, "OK: all coverage thresholds are met"); err != nil {
+			return nil, fmt.Errorf("failed to write result: %w", err)
+		}
+	}
+
+	return violations, nil
+}
+
+type statements struct {
+	total, covered int64
+}
+
+var checkOrder = map[CheckCategory]int{CheckTotal: 0, CheckPackage: 1, CheckFile: 2, CheckPatch: 3}
+
+func checkThreshold(
+	violations []Violation, category CheckCategory, name string, covered, total int64, required float64,
+) []Violation {
+	if required <= 0 {
+		return violations
+	}
+
+	if actual := model.Percent(covered, total); actual < required {
+		violations = append(violations, Violation{
+			Category: category,
+			Name:     name,
+			Actual:   actual,
+			Required: required,
+		})
+	}
+
+	return violations
+}
+
+// patchStatements returns the statements of all the changed files.
+func patchStatements(c *Coverage) (total, covered int64) {
+	profiles := profilesByName(c.Profiles)
+
+	for file, lines := range c.ChangedLines {
+		if p, ok := profiles[file]; ok {
+			fileTotal, fileCovered := model.PatchStatements(p, lines)
+			total += fileTotal
+			covered += fileCovered
+		}
+	}
+
+	return total, covered
+}
@@ -0,0 +1,40 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	t.Run("all thresholds met", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		violations, err := report.Check(buf, testCoverage(), report.Thresholds{Total: 50, File: 30})
+		require.NoError(t, err)
+		require.Empty(t, violations)
+		require.Equal(t, "OK: all coverage thresholds are met\n", buf.String())
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		c := testCoverage()
+		c.ChangedLines = map[string][]int{"foo.go": {8}}
+
+		buf := bytes.NewBuffer(nil)
+		violations, err := report.Check(buf, c, report.Thresholds{Total: 60, Package: 50, File: 40, Patch: 10})
+		require.NoError(t, err)
+		require.Len(t, violations, 4)
+
+		expected := "FAIL total coverage: 50.00% < 60.00% required\n" +
+			"FAIL package example.com/foo: 33.33% < 50.00% required\n" +
+			"FAIL file foo.go: 33.33% < 40.00% required\n" +
+			"FAIL patch coverage: 0.00% < 10.00% required\n"
+		require.Equal(t, expected, buf.String())
+	})
+
+	t.Run("patch without diff", func(t *testing.T) {
+		_, err := report.Check(bytes.NewBuffer(nil), testCoverage(), report.Thresholds{Patch: 10})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"golang.org/x/tools/cover"
+)
+
+const coberturaDocType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int64              `xml:"lines-covered,attr"`
+	LinesValid      int64              `xml:"lines-valid,attr"`
+	BranchesCovered int64              `xml:"branches-covered,attr"`
+	BranchesValid   int64              `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+
+	linesCovered, linesValid int64
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// Cobertura renders the coverage as Cobertura XML. Every directory becomes a
+// package named after its Go import path, and every file becomes a class.
+// Go coverage profiles do not include branches, so branch rates are zero.
+func Cobertura(w io.Writer, c *Coverage) error {
+	doc := coberturaCoverage{
+		Version:   "gocovsh",
+		Timestamp: time.Now().UnixMilli(),
+		Sources:   []string{c.ModuleName},
+	}
+
+	packages := map[string]*coberturaPackage{}
+
+	for _, p := range c.Profiles {
+		dir := path.Dir(p.FileName)
+
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &coberturaPackage{Name: c.packagePath(p.FileName)}
+			packages[dir] = pkg
+		}
+
+		class, covered, valid := coberturaFileClass(p)
+		pkg.Classes = append(pkg.Classes, class)
+		pkg.linesCovered += covered
+		pkg.linesValid += valid
+	}
+
+	for _, pkg := range packages {
+		pkg.LineRate = rate(pkg.linesCovered, pkg.linesValid)
+		doc.LinesCovered += pkg.linesCovered
+		doc.LinesValid += pkg.linesValid
+		doc.Packages = append(doc.Packages, *pkg)
+	}
+
+	sort.Slice(doc.Packages, func(i, j int) bool {
+		return doc.Packages[i].Name < doc.Packages[j].Name
+	})
+
+	doc.LineRate = rate(doc.LinesCovered, doc.LinesValid)
+
+	if _, err := fmt.Fprintf(w, "%s%s\n", xml.Header, coberturaDocType); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	_, err := fmt.Fprintln(w)
+
+	return err
+}
+
+func coberturaFileClass(p *cover.Profile) (class coberturaClass, covered, valid int64) {
+	numbers, hits := lineHits(p)
+	class = coberturaClass{
+		Name:     path.Base(p.FileName),
+		Filename: p.FileName,
+		Lines:    make([]coberturaLine, len(numbers)),
+	}
+
+	for i, n := range numbers {
+		class.Lines[i] = coberturaLine{Number: n, Hits: hits[n]}
+
+		if hits[n] > 0 {
+			covered++
+		}
+	}
+
+	valid = int64(len(numbers))
+	class.LineRate = rate(covered, valid)
+
+	return class, covered, valid
+}
+
+// lineHits returns the sorted numbers of all the lines that belong to the
+// statement blocks of the profile, and the hit count of every such line.
+// Lines shared by several blocks use the highest count among them.
+func lineHits(p *cover.Profile) ([]int, map[int]int) {
+	hits := map[int]int{}
+
+	for _, b := range p.Blocks {
+		if b.NumStmt == 0 {
+			continue
+		}
+
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			if count, ok := hits[line]; !ok || b.Count > count {
+				hits[line] = b.Count
+			}
+		}
+	}
+
+	numbers := make([]int, 0, len(hits))
+
+	for line := range hits {
+		numbers = append(numbers, line)
+	}
+
+	sort.Ints(numbers)
+
+	return numbers, hits
+}
+
+// rate returns the covered part of the total as a fraction between 0 and 1.
+func rate(covered, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(covered) / float64(total)
+}
@@ -0,0 +1,61 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+type coberturaDoc struct {
+	LineRate     float64  `xml:"line-rate,attr"`
+	LinesCovered int      `xml:"lines-covered,attr"`
+	LinesValid   int      `xml:"lines-valid,attr"`
+	Sources      []string `xml:"sources>source"`
+	Packages     []struct {
+		Name     string  `xml:"name,attr"`
+		LineRate float64 `xml:"line-rate,attr"`
+		Classes  []struct {
+			Name     string  `xml:"name,attr"`
+			Filename string  `xml:"filename,attr"`
+			LineRate float64 `xml:"line-rate,attr"`
+			Lines    []struct {
+				Number int `xml:"number,attr"`
+				Hits   int `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+func TestCobertura(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf, report.FormatCobertura, testCoverage()))
+	require.Contains(t, buf.String(), "<!DOCTYPE coverage")
+
+	var doc coberturaDoc
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	require.Equal(t, []string{"example.com/foo"}, doc.Sources)
+	require.Equal(t, 10, doc.LinesValid)
+	require.Equal(t, 6, doc.LinesCovered)
+	require.Equal(t, 0.6, doc.LineRate)
+
+	require.Len(t, doc.Packages, 2)
+	require.Equal(t, "example.com/foo", doc.Packages[0].Name)
+	require.Equal(t, "example.com/foo/bar", doc.Packages[1].Name)
+	require.Equal(t, 1.0, doc.Packages[1].LineRate)
+
+	class := doc.Packages[0].Classes[0]
+	require.Equal(t, "foo.go", class.Name)
+	require.Equal(t, "foo.go", class.Filename)
+	require.InDelta(t, 3.0/7, class.LineRate, 1e-9)
+	require.Len(t, class.Lines, 7)
+	require.Equal(t, 3, class.Lines[0].Number)
+	require.Equal(t, 1, class.Lines[0].Hits)
+	require.Equal(t, 7, class.Lines[3].Number)
+	require.Equal(t, 0, class.Lines[3].Hits)
+
+	require.Equal(t, "bar/bar.go", doc.Packages[1].Classes[0].Filename)
+}
@@ -0,0 +1,265 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/orlangure/gocovsh/internal/codeview"
+	"github.com/orlangure/gocovsh/internal/model"
+	"github.com/orlangure/gocovsh/internal/styles"
+	"golang.org/x/tools/cover"
+)
+
+const (
+	htmlIndexFile = "index.html"
+	htmlFilesDir  = "files"
+)
+
+var htmlTemplates = template.Must(template.New("html").Parse(`
+{{- define "style" -}}
+<style>
+:root {
+	--background: {{ .Theme.BackgroundColor }};
+	--text: {{ .Theme.TextColor }};
+	--covered: {{ .Theme.PrimaryColor }};
+	--uncovered: {{ .Theme.SecondaryColor }};
+	--neutral: {{ .Theme.InactiveColor }};
+}
+body { background: var(--background); color: var(--text); font-family: monospace; margin: 2em; }
+a { color: var(--text); }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 1em; text-align: left; }
+td.number { text-align: right; }
+input { background: var(--background); color: var(--text); border: 1px solid var(--neutral); padding: 0.3em; margin-bottom: 1em; }
+.neutral, .line-number { color: var(--neutral); }
+.covered { color: var(--covered); }
+.uncovered { color: var(--uncovered); }
+.code { white-space: pre; tab-size: 4; }
+.separator td { border-top: 1px solid var(--neutral); height: 0.5em; }
+</style>
+{{- end -}}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage: {{ .Module }}</title>
+{{ template "style" . }}
+</head>
+<body>
+<h1>{{ .Module }}</h1>
+<p>Total coverage: {{ printf "%.2f%%" .Percentage }} ({{ .Covered }} of {{ .Statements }} statements)</p>
+<input id="filter" type="text" placeholder="Filter files..." autofocus>
+<table>
+<thead><tr><th>File</th><th>Statements</th><th>Covered</th><th>Coverage</th></tr></thead>
+<tbody id="files">
+{{- range .Files }}
+<tr data-name="{{ .Name }}"><td><a href="{{ .Link }}">{{ .Name }}</a></td><td class="number">{{ .Statements }}</td><td class="number">{{ .Covered }}</td><td class="number neutral">{{ printf "%.2f%%" .Percentage }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+<script>
+document.getElementById("filter").addEventListener("input", function (e) {
+	var query = e.target.value.toLowerCase();
+	document.querySelectorAll("#files tr").forEach(function (row) {
+		row.style.display = row.dataset.name.toLowerCase().indexOf(query) === -1 ? "none" : "";
+	});
+});
+</script>
+</body>
+</html>
+{{ end -}}
+
+{{- define "file" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Name }}</title>
+{{ template "style" . }}
+</head>
+<body>
+<p><a href="{{ .IndexLink }}">&larr; All files</a></p>
+<h1>{{ .Name }}</h1>
+<p>Coverage: {{ printf "%.2f%%" .Percentage }} ({{ .Covered }} of {{ .Statements }} statements)</p>
+{{- if .Error }}
+<p class="uncovered">Source code is not available: {{ .Error }}</p>
+{{- else }}
+<table>
+{{- range .Lines }}
+{{- if .Separator }}
+<tr class="separator"><td></td><td></td><td></td></tr>
+{{- end }}
+<tr><td>{{ if .Changed }}<span class="covered">+</span>{{ end }}</td><td class="number line-number">{{ .Number }}</td><td class="code">
+{{- range .Parts }}<span class="{{ .Class }}">{{ .Text }}</span>{{ end -}}
+</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+{{ end -}}
+`))
+
+type htmlIndex struct {
+	Theme      styles.Theme
+	Module     string
+	Statements int64
+	Covered    int64
+	Percentage float64
+	Files      []htmlFile
+}
+
+type htmlFile struct {
+	Theme      styles.Theme
+	Name       string
+	Link       string
+	IndexLink  string
+	Statements int64
+	Covered    int64
+	Percentage float64
+	Lines      []htmlLine
+	Error      error
+}
+
+type htmlLine struct {
+	Number    int
+	Parts     []htmlLinePart
+	Changed   bool
+	Separator bool
+}
+
+type htmlLinePart struct {
+	Text  string
+	Class string
+}
+
+// HTML writes a self-contained static site into the provided directory: an
+// index page with all the files of the report, and a page for every file with
+// its source code highlighted. When the diff is provided, only the changed
+// lines are displayed.
+func HTML(dir string, c *Coverage) error {
+	index := htmlIndex{
+		Theme:  styles.CurrentTheme,
+		Module: c.ModuleName,
+		Files:  make([]htmlFile, 0, len(c.Profiles)),
+	}
+
+	for _, p := range c.Profiles {
+		total, covered := model.CountStatements(p)
+		index.Statements += total
+		index.Covered += covered
+
+		f := htmlFile{
+			Theme:      styles.CurrentTheme,
+			Name:       p.FileName,
+			Link:       path.Join(htmlFilesDir, p.FileName+".html"),
+			IndexLink:  strings.Repeat("../", strings.Count(p.FileName, "/")+1) + htmlIndexFile,
+			Statements: total,
+			Covered:    covered,
+			Percentage: model.Percent(covered, total),
+		}
+
+		f.Lines, f.Error = htmlLines(c, p)
+
+		if err := writeHTMLFile(filepath.Join(dir, filepath.FromSlash(f.Link)), "file", f); err != nil {
+			return err
+		}
+
+		index.Files = append(index.Files, f)
+	}
+
+	index.Percentage = model.Percent(index.Covered, index.Statements)
+
+	return writeHTMLFile(filepath.Join(dir, htmlIndexFile), "index", index)
+}
+
+func htmlLines(c *Coverage, p *cover.Profile) ([]htmlLine, error) {
+	lines, err := model.ReadLines(c.sourceFile(p.FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	annotatedLines, err := model.AnnotateLines(lines, p)
+	if err != nil {
+		return nil, fmt.Errorf("coverage data doesn't match the source: %w", err)
+	}
+
+	numbers := make([]int, 0, len(annotatedLines))
+	contextLines := map[int]bool{}
+	changed := false
+
+	if changedLines := c.ChangedLines[p.FileName]; len(changedLines) > 0 {
+		numbers, contextLines = codeview.WithContext(changedLines)
+		changed = true
+	} else {
+		for i := range annotatedLines {
+			numbers = append(numbers, i+1)
+		}
+	}
+
+	htmlLines := make([]htmlLine, 0, len(numbers))
+	lastLine := 0
+
+	for _, n := range numbers {
+		if n > len(annotatedLines) {
+			break
+		}
+
+		line := htmlLine{
+			Number:    n,
+			Changed:   changed && !contextLines[n],
+			Separator: lastLine > 0 && n-lastLine > 1,
+		}
+
+		for _, part := range annotatedLines[n-1] {
+			line.Parts = append(line.Parts, htmlLinePart{
+				Text:  part.Text,
+				Class: htmlCoverageClass(part.Coverage),
+			})
+		}
+
+		htmlLines = append(htmlLines, line)
+		lastLine = n
+	}
+
+	return htmlLines, nil
+}
+
+func htmlCoverageClass(c model.LineCoverage) string {
+	switch c {
+	case model.LineCovered:
+		return "covered"
+	case model.LineUncovered:
+		return "uncovered"
+	default:
+		return "neutral"
+	}
+}
+
+func writeHTMLFile(filename, templateName string, data interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", filename, err)
+	}
+
+	f, err := os.Create(filename) // nolint: gosec
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filename, err)
+	}
+
+	if err := htmlTemplates.ExecuteTemplate(f, templateName, data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to render %s: %w", filename, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+
+	return nil
+}
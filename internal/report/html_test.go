@@ -0,0 +1,78 @@
+package report_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+const generalCodeRoot = "../gocovshtest/testdata/general"
+
+func generalCoverage(t *testing.T) *report.Coverage {
+	t.Helper()
+
+	profiles, err := cover.ParseProfiles(filepath.Join(generalCodeRoot, "profile.cover"))
+	require.NoError(t, err)
+
+	for _, p := range profiles {
+		p.FileName = filepath.Base(p.FileName)
+	}
+
+	return &report.Coverage{
+		ModuleName: "github.com/orlangure/gocovsh/internal/model/testdata/general",
+		CodeRoot:   generalCodeRoot,
+		Profiles:   profiles,
+	}
+}
+
+func TestHTML(t *testing.T) {
+	t.Run("full files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, report.HTML(dir, generalCoverage(t)))
+
+		index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+		require.NoError(t, err)
+		require.Contains(t, string(index), `<a href="files/covered.go.html">covered.go</a>`)
+		require.Contains(t, string(index), "Total coverage: 80.00%")
+
+		page, err := os.ReadFile(filepath.Join(dir, "files", "covered.go.html"))
+		require.NoError(t, err)
+		require.Contains(t, string(page), `<a href="../index.html">`)
+		require.Contains(t, string(page), `<span class="neutral">func Full() string </span><span class="covered">{</span>`)
+		require.NotContains(t, string(page), `class="separator"`)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		c := generalCoverage(t)
+		c.ChangedLines = map[string][]int{
+			"partial_with_a_very_long_name_to_trigger_ellipsis_in_the_output.go": {8},
+		}
+
+		dir := t.TempDir()
+		require.NoError(t, report.HTML(dir, c))
+
+		page, err := os.ReadFile(filepath.Join(
+			dir, "files", "partial_with_a_very_long_name_to_trigger_ellipsis_in_the_output.go.html",
+		))
+		require.NoError(t, err)
+		require.Contains(t, string(page), `<span class="covered">+</span></td><td class="number line-number">8</td>`)
+		require.Contains(t, string(page), `<td class="number line-number">7</td>`)
+		require.NotContains(t, string(page), `<td class="number line-number">3</td>`)
+	})
+
+	t.Run("missing source file", func(t *testing.T) {
+		c := generalCoverage(t)
+		c.Profiles[0].FileName = "missing.go"
+
+		dir := t.TempDir()
+		require.NoError(t, report.HTML(dir, c))
+
+		page, err := os.ReadFile(filepath.Join(dir, "files", "missing.go.html"))
+		require.NoError(t, err)
+		require.Contains(t, string(page), "Source code is not available")
+	})
+}
@@ -0,0 +1,127 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/orlangure/gocovsh/internal/model"
+)
+
+// JSONSchemaVersion is the version of the JSON report schema. It changes
+// every time a field is removed or its meaning changes; new fields may be
+// added without changing the version.
+const JSONSchemaVersion = 1
+
+// JSONReport is the root object of the JSON report.
+type JSONReport struct {
+	// SchemaVersion is always set to JSONSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+
+	// Module is the Go module name found in go.mod file.
+	Module string `json:"module"`
+
+	// Mode is the coverage mode: set, count or atomic.
+	Mode string `json:"mode"`
+
+	// Statements and Covered are the totals of all the files in the report.
+	Statements int64 `json:"statements"`
+	Covered    int64 `json:"covered"`
+
+	// Percentage is the total coverage of all the files in the report.
+	Percentage float64 `json:"percentage"`
+
+	Files []JSONFile `json:"files"`
+}
+
+// JSONFile is a single source file of the JSON report.
+type JSONFile struct {
+	// Name is the file path relative to the module root.
+	Name string `json:"name"`
+
+	Statements int64   `json:"statements"`
+	Covered    int64   `json:"covered"`
+	Percentage float64 `json:"percentage"`
+
+	// Blocks are the coverage blocks exactly as they appear in the profile.
+	Blocks []JSONBlock `json:"blocks"`
+
+	// ChangedLines are the line numbers changed in the diff. This field only
+	// appears when the diff is provided as input.
+	ChangedLines []int `json:"changedLines,omitempty"`
+}
+
+// JSONBlock is a single coverage block. Lines and columns are 1-based.
+type JSONBlock struct {
+	StartLine int `json:"startLine"`
+	StartCol  int `json:"startCol"`
+	EndLine   int `json:"endLine"`
+	EndCol    int `json:"endCol"`
+	NumStmt   int `json:"numStmt"`
+	Count     int `json:"count"`
+}
+
+// JSON renders the coverage as a JSON document described by JSONReport.
+func JSON(w io.Writer, c *Coverage) error {
+	r := JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        c.ModuleName,
+		Files:         make([]JSONFile, 0, len(c.Profiles)),
+	}
+
+	for _, p := range c.Profiles {
+		total, covered := model.CountStatements(p)
+		r.Mode = p.Mode
+		r.Statements += total
+		r.Covered += covered
+
+		f := JSONFile{
+			Name:         p.FileName,
+			Statements:   total,
+			Covered:      covered,
+			Percentage:   model.Percent(covered, total),
+			Blocks:       make([]JSONBlock, len(p.Blocks)),
+			ChangedLines: changedLines(c, p.FileName),
+		}
+
+		for i, b := range p.Blocks {
+			f.Blocks[i] = JSONBlock{
+				StartLine: b.StartLine,
+				StartCol:  b.StartCol,
+				EndLine:   b.EndLine,
+				EndCol:    b.EndCol,
+				NumStmt:   b.NumStmt,
+				Count:     b.Count,
+			}
+		}
+
+		r.Files = append(r.Files, f)
+	}
+
+	r.Percentage = model.Percent(r.Covered, r.Statements)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	return nil
+}
+
+// changedLines returns a sorted copy of the lines changed in the given file,
+// or nil if there is no diff.
+func changedLines(c *Coverage, fileName string) []int {
+	lines, ok := c.ChangedLines[fileName]
+	if !ok {
+		return nil
+	}
+
+	sorted := make([]int, len(lines))
+	copy(sorted, lines)
+	sort.Ints(sorted)
+
+	return sorted
+}
@@ -0,0 +1,41 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	c := testCoverage()
+	c.ChangedLines = map[string][]int{"foo.go": {8, 4}}
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf, report.FormatJSON, c))
+
+	var r report.JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+
+	require.Equal(t, report.JSONSchemaVersion, r.SchemaVersion)
+	require.Equal(t, "example.com/foo", r.Module)
+	require.Equal(t, "set", r.Mode)
+	require.EqualValues(t, 4, r.Statements)
+	require.EqualValues(t, 2, r.Covered)
+	require.Equal(t, 50.0, r.Percentage)
+
+	require.Len(t, r.Files, 2)
+	require.Equal(t, "foo.go", r.Files[0].Name)
+	require.EqualValues(t, 3, r.Files[0].Statements)
+	require.EqualValues(t, 1, r.Files[0].Covered)
+	require.Equal(t, []int{4, 8}, r.Files[0].ChangedLines)
+	require.Equal(t, report.JSONBlock{
+		StartLine: 7, StartCol: 20, EndLine: 10, EndCol: 2, NumStmt: 2, Count: 0,
+	}, r.Files[0].Blocks[1])
+
+	require.Equal(t, "bar/bar.go", r.Files[1].Name)
+	require.Nil(t, r.Files[1].ChangedLines)
+	require.NotContains(t, buf.String(), `"changedLines": null`)
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/orlangure/gocovsh/internal/model"
+	"golang.org/x/tools/cover"
+)
+
+// LCOV renders the coverage in the LCOV tracefile format, which is supported
+// by many editor plugins. Function records are found by parsing the source
+// code; if a file can't be parsed, only its line records are written.
+func LCOV(w io.Writer, c *Coverage) error {
+	bw := bufio.NewWriter(w)
+
+	for _, p := range c.Profiles {
+		writeLCOVRecord(bw, c, p)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+func writeLCOVRecord(w io.Writer, c *Coverage, p *cover.Profile) {
+	filename := c.sourceFile(p.FileName)
+
+	fmt.Fprintf(w, "TN:\nSF:%s\n", filename)
+
+	funcs, err := model.Funcs(filename, p)
+	if err != nil {
+		log.Println("skipping functions of", p.FileName, err)
+	}
+
+	funcsHit := 0
+
+	for _, fn := range funcs {
+		fmt.Fprintf(w, "FN:%d,%s\n", fn.StartLine, fn.Name)
+	}
+
+	for _, fn := range funcs {
+		fmt.Fprintf(w, "FNDA:%d,%s\n", fn.Count, fn.Name)
+
+		if fn.Count > 0 {
+			funcsHit++
+		}
+	}
+
+	fmt.Fprintf(w, "FNF:%d\nFNH:%d\n", len(funcs), funcsHit)
+
+	numbers, hits := lineHits(p)
+	linesHit := 0
+
+	for _, n := range numbers {
+		fmt.Fprintf(w, "DA:%d,%d\n", n, hits[n])
+
+		if hits[n] > 0 {
+			linesHit++
+		}
+	}
+
+	fmt.Fprintf(w, "LF:%d\nLH:%d\nend_of_record\n", len(numbers), linesHit)
+}
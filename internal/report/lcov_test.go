@@ -0,0 +1,55 @@
+package report_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLCOV(t *testing.T) {
+	c := generalCoverage(t)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf, report.FormatLCOV, c))
+
+	records := strings.SplitAfter(buf.String(), "end_of_record\n")
+	require.Len(t, records, 3)
+	require.Empty(t, records[2])
+
+	expected := "TN:\n" +
+		"SF:" + filepath.Join(generalCodeRoot, partialFile) + "\n" +
+		"FN:3,Covered\n" +
+		"FN:7,NotCovered\n" +
+		"FN:11,SecondCovered\n" +
+		"FNDA:1,Covered\n" +
+		"FNDA:0,NotCovered\n" +
+		"FNDA:1,SecondCovered\n" +
+		"FNF:3\n" +
+		"FNH:2\n" +
+		"DA:3,1\n" +
+		"DA:4,1\n" +
+		"DA:5,1\n" +
+		"DA:7,0\n" +
+		"DA:8,0\n" +
+		"DA:9,0\n" +
+		"DA:11,1\n" +
+		"DA:12,1\n" +
+		"DA:16,1\n" +
+		"LF:9\n" +
+		"LH:6\n" +
+		"end_of_record\n"
+	require.Equal(t, expected, records[1])
+
+	t.Run("missing source file", func(t *testing.T) {
+		c := generalCoverage(t)
+		c.Profiles[0].FileName = "missing.go"
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, report.Write(buf, report.FormatLCOV, c))
+		require.Contains(t, buf.String(), "FNF:0\nFNH:0\nDA:3,1\n")
+	})
+}
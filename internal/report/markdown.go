@@ -0,0 +1,202 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/orlangure/gocovsh/internal/codeview"
+	"github.com/orlangure/gocovsh/internal/model"
+	"golang.org/x/tools/cover"
+)
+
+// DefaultMarkdownLimit keeps the markdown report below the maximum size of a
+// pull request comment on popular code hosting platforms.
+const DefaultMarkdownLimit = 65000
+
+// markdownReserve is the space kept for the truncation notice.
+const markdownReserve = 200
+
+var errDiffRequired = errors.New("this report requires a diff on stdin, for example: git diff | gocovsh")
+
+// Markdown renders the patch coverage of every changed file, followed by
+// snippets of uncovered changed lines. It is meant to be posted as a pull
+// request comment, and is truncated to fit DefaultMarkdownLimit.
+func Markdown(w io.Writer, c *Coverage) error {
+	return MarkdownWithLimit(w, c, DefaultMarkdownLimit)
+}
+
+// MarkdownWithLimit works like Markdown, but truncates the output to fit the
+// provided limit of bytes.
+func MarkdownWithLimit(w io.Writer, c *Coverage, limit int) error {
+	if c.ChangedLines == nil {
+		return errDiffRequired
+	}
+
+	var (
+		total, covered int64
+		rows           []string
+		snippets       []string
+		profiles       = profilesByName(c.Profiles)
+	)
+
+	for _, file := range changedFiles(c) {
+		changedLines := c.ChangedLines[file]
+
+		p, ok := profiles[file]
+		if !ok {
+			rows = append(rows, fmt.Sprintf("| `%s` | n/a | no coverage data |", file))
+			continue
+		}
+
+		fileTotal, fileCovered := model.PatchStatements(p, changedLines)
+		total += fileTotal
+		covered += fileCovered
+
+		rows = append(rows, fmt.Sprintf(
+			"| `%s` | %s | %d/%d |",
+			file, formatPatchPercent(fileCovered, fileTotal), fileCovered, fileTotal,
+		))
+
+		if uncovered := model.UncoveredLines(p, changedLines); len(uncovered) > 0 {
+			snippets = append(snippets, markdownSnippet(c, p, uncovered))
+		}
+	}
+
+	b := &truncatedBuilder{limit: limit - markdownReserve}
+	b.add(markdownHeader(covered, total))
+
+	if len(rows) == 0 {
+		b.add("No Go files were changed.\n")
+	} else {
+		b.add("| File | Patch coverage | Covered statements |\n| --- | ---: | ---: |\n")
+		b.addAll(rows, "\n")
+	}
+
+	if len(snippets) > 0 {
+		b.add("\n### Uncovered changed lines\n\nUncovered lines are marked with `!`.\n")
+		b.addAll(snippets, "")
+	}
+
+	if b.skipped > 0 {
+		b.WriteString(fmt.Sprintf(
+			"\n_The report is truncated: %d more entries did not fit into the size limit._\n",
+			b.skipped,
+		))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+func markdownHeader(covered, total int64) string {
+	if total == 0 {
+		return "## Patch coverage: n/a\n\nThe changes do not include any statements.\n\n"
+	}
+
+	return fmt.Sprintf(
+		"## Patch coverage: %.2f%%\n\n%d of %d changed statements are covered.\n\n",
+		model.Percent(covered, total), covered, total,
+	)
+}
+
+func markdownSnippet(c *Coverage, p *cover.Profile, uncovered []int) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "\n#### `%s`\n\n", p.FileName)
+
+	lines, err := model.ReadLines(c.sourceFile(p.FileName))
+	if err != nil {
+		fmt.Fprintf(&sb, "Source code is not available: %s\n", err)
+		return sb.String()
+	}
+
+	numbers, contextLines := codeview.WithContext(uncovered)
+	numberWidth := len(fmt.Sprintf("%d", numbers[len(numbers)-1]))
+	lastLine := 0
+
+	sb.WriteString("```go\n")
+
+	for _, n := range numbers {
+		if n > len(lines) {
+			break
+		}
+
+		if lastLine > 0 && n-lastLine > 1 {
+			sb.WriteString("...\n")
+		}
+
+		marker := "!"
+		if contextLines[n] {
+			marker = " "
+		}
+
+		line := strings.ReplaceAll(lines[n-1], "\t", "    ")
+		fmt.Fprintf(&sb, "%s %*d  %s\n", marker, numberWidth, n, line)
+
+		lastLine = n
+	}
+
+	sb.WriteString("```\n")
+
+	return sb.String()
+}
+
+func formatPatchPercent(covered, total int64) string {
+	if total == 0 {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("%.2f%%", model.Percent(covered, total))
+}
+
+// changedFiles returns the names of all the files in the diff, sorted.
+func changedFiles(c *Coverage) []string {
+	files := make([]string, 0, len(c.ChangedLines))
+
+	for file := range c.ChangedLines {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+func profilesByName(profiles []*cover.Profile) map[string]*cover.Profile {
+	m := make(map[string]*cover.Profile, len(profiles))
+
+	for _, p := range profiles {
+		m[p.FileName] = p
+	}
+
+	return m
+}
+
+// truncatedBuilder collects the report pieces while they fit into the limit,
+// and counts the pieces that were skipped.
+type truncatedBuilder struct {
+	strings.Builder
+	limit   int
+	skipped int
+}
+
+func (b *truncatedBuilder) add(s string) {
+	if b.skipped > 0 || b.Len()+len(s) > b.limit {
+		b.skipped++
+		return
+	}
+
+	b.WriteString(s)
+}
+
+func (b *truncatedBuilder) addAll(pieces []string, suffix string) {
+	for _, s := range pieces {
+		b.add(s + suffix)
+	}
+}
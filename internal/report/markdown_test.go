@@ -0,0 +1,62 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+)
+
+const partialFile = "partial_with_a_very_long_name_to_trigger_ellipsis_in_the_output.go"
+
+func TestMarkdown(t *testing.T) {
+	t.Run("patch coverage", func(t *testing.T) {
+		c := generalCoverage(t)
+		c.ChangedLines = map[string][]int{
+			partialFile:       {4, 8},
+			"covered.go":      {4},
+			"covered_test.go": {10},
+		}
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, report.Write(buf, report.FormatMarkdown, c))
+
+		expected := "## Patch coverage: 66.67%\n\n" +
+			"2 of 3 changed statements are covered.\n\n" +
+			"| File | Patch coverage | Covered statements |\n" +
+			"| --- | ---: | ---: |\n" +
+			"| `covered.go` | 100.00% | 1/1 |\n" +
+			"| `covered_test.go` | n/a | no coverage data |\n" +
+			"| `" + partialFile + "` | 50.00% | 1/2 |\n" +
+			"\n### Uncovered changed lines\n\n" +
+			"Uncovered lines are marked with `!`.\n" +
+			"\n#### `" + partialFile + "`\n\n" +
+			"```go\n" +
+			"  7  func NotCovered() string {\n" +
+			"! 8      return \"not covered\"\n" +
+			"  9  }\n" +
+			"```\n"
+		require.Equal(t, expected, buf.String())
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		c := generalCoverage(t)
+		c.ChangedLines = map[string][]int{
+			partialFile:  {8},
+			"covered.go": {4},
+		}
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, report.MarkdownWithLimit(buf, c, 450))
+		require.Contains(t, buf.String(), "| `covered.go` | 100.00% | 1/1 |")
+		require.NotContains(t, buf.String(), "```go")
+		require.Contains(t, buf.String(), "The report is truncated: 3 more entries")
+		require.LessOrEqual(t, buf.Len(), 450)
+	})
+
+	t.Run("no diff", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.Error(t, report.Write(buf, report.FormatMarkdown, generalCoverage(t)))
+	})
+}
@@ -0,0 +1,15 @@
+package report
+
+// Option is a function that can be passed to Write to adjust the reports.
+type Option func(*options)
+
+type options struct {
+	annotationLevel AnnotationLevel
+}
+
+// WithAnnotationLevel sets the severity of CI annotations.
+func WithAnnotationLevel(level AnnotationLevel) Option {
+	return func(o *options) {
+		o.annotationLevel = level
+	}
+}
@@ -0,0 +1,119 @@
+// Package report renders coverage profiles in non-interactive formats. The
+// reports are meant to be used in CI or in any other environment where the
+// interactive terminal UI is not available.
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/orlangure/gocovsh/internal/model"
+	"golang.org/x/tools/cover"
+)
+
+// Format is the name of a supported report format.
+type Format string
+
+// Supported report formats.
+const (
+	FormatText        Format = "text"
+	FormatJSON        Format = "json"
+	FormatMarkdown    Format = "markdown"
+	FormatCobertura   Format = "cobertura"
+	FormatLCOV        Format = "lcov"
+	FormatSARIF       Format = "sarif"
+	FormatAnnotations Format = "annotations"
+)
+
+// Formats lists all the supported report formats.
+var Formats = []Format{
+	FormatText, FormatJSON, FormatMarkdown, FormatCobertura, FormatLCOV, FormatSARIF,
+	FormatAnnotations,
+}
+
+// Coverage is the input of every report: the profiles that should be
+// rendered, already filtered and sorted.
+type Coverage struct {
+	// ModuleName is the name of the Go module the profiles belong to.
+	ModuleName string
+
+	// Modules are all the Go modules the profiles belong to, when there are
+	// several modules in a workspace or in nested directories.
+	Modules []model.Module
+
+	// CodeRoot is the directory that contains the source code files.
+	CodeRoot string
+
+	// Sources are the locations of the files of other modules, by their
+	// import paths.
+	Sources map[string]string
+
+	// Profiles are the coverage profiles with file names relative to the
+	// module root.
+	Profiles []*cover.Profile
+
+	// ChangedLines are the lines of every file changed in the diff, if the
+	// diff was provided.
+	ChangedLines map[string][]int
+}
+
+// packagePath returns the import path of the package of the file, based on
+// the module the file belongs to.
+func (c *Coverage) packagePath(fileName string) string {
+	dir := path.Dir(fileName)
+
+	mod := model.ModuleOf(c.Modules, fileName)
+	if mod == nil {
+		return path.Join(c.ModuleName, dir)
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(dir, mod.Dir), "/")
+	if mod.Dir == "." {
+		rel = dir
+	}
+
+	return path.Join(mod.Path, rel)
+}
+
+// sourceFile returns the location of the source code of the file.
+func (c *Coverage) sourceFile(fileName string) string {
+	if filename, ok := c.Sources[fileName]; ok {
+		return filename
+	}
+
+	return filepath.Join(c.CodeRoot, filepath.FromSlash(fileName))
+}
+
+// Write renders the coverage in the requested format. Optional configuration
+// is available using `With...` functions.
+func Write(w io.Writer, format Format, c *Coverage, opts ...Option) error {
+	o := options{
+		annotationLevel: AnnotationWarning,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch format {
+	case FormatText:
+		return Text(w, c)
+	case FormatJSON:
+		return JSON(w, c)
+	case FormatMarkdown:
+		return Markdown(w, c)
+	case FormatCobertura:
+		return Cobertura(w, c)
+	case FormatLCOV:
+		return LCOV(w, c)
+	case FormatSARIF:
+		return SARIF(w, c)
+	case FormatAnnotations:
+		return Annotations(w, c, o.annotationLevel)
+	default:
+		return fmt.Errorf("unsupported report format: %q", format)
+	}
+}
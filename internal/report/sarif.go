@@ -0,0 +1,191 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/orlangure/gocovsh/internal/model"
+	"golang.org/x/tools/cover"
+)
+
+const (
+	sarifSchema        = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion       = "2.1.0"
+	sarifToolName      = "gocovsh"
+	sarifToolURI       = "https://github.com/orlangure/gocovsh"
+	sarifSourceRoot    = "SRCROOT"
+	sarifWarning       = "warning"
+	sarifRuleUncovered = "uncovered"
+	sarifRulePartial   = "partially-covered"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+var sarifRules = []sarifRule{
+	{
+		ID:                   sarifRuleUncovered,
+		ShortDescription:     sarifMessage{Text: "Changed statements are not covered by tests"},
+		DefaultConfiguration: sarifConfiguration{Level: sarifWarning},
+	},
+	{
+		ID:                   sarifRulePartial,
+		ShortDescription:     sarifMessage{Text: "Changed lines are only partially covered by tests"},
+		DefaultConfiguration: sarifConfiguration{Level: sarifWarning},
+	},
+}
+
+// SARIF renders every uncovered statement block that intersects with the
+// changed lines as a SARIF result. Blocks that share their changed lines with
+// covered blocks, such as a single line "if err != nil { return err }", are
+// reported as partially covered. It requires a diff.
+func SARIF(w io.Writer, c *Coverage) error {
+	if c.ChangedLines == nil {
+		return errDiffRequired
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			InformationURI: sarifToolURI,
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+
+	profiles := profilesByName(c.Profiles)
+
+	for _, file := range changedFiles(c) {
+		p, ok := profiles[file]
+		if !ok {
+			continue
+		}
+
+		run.Results = append(run.Results, sarifFileResults(p, c.ChangedLines[file])...)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	return nil
+}
+
+func sarifFileResults(p *cover.Profile, changedLines []int) []sarifResult {
+	uncoveredLines := map[int]bool{}
+	for _, line := range model.UncoveredLines(p, changedLines) {
+		uncoveredLines[line] = true
+	}
+
+	var results []sarifResult
+
+	for _, b := range p.Blocks {
+		if b.NumStmt == 0 || b.Count > 0 {
+			continue
+		}
+
+		changedInBlock, uncoveredInBlock := 0, 0
+
+		for _, line := range changedLines {
+			if line >= b.StartLine && line <= b.EndLine {
+				changedInBlock++
+
+				if uncoveredLines[line] {
+					uncoveredInBlock++
+				}
+			}
+		}
+
+		if changedInBlock == 0 {
+			continue
+		}
+
+		ruleIndex := 0
+		if uncoveredInBlock < changedInBlock {
+			ruleIndex = 1
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    sarifRules[ruleIndex].ID,
+			RuleIndex: ruleIndex,
+			Level:     sarifWarning,
+			Message:   sarifRules[ruleIndex].ShortDescription,
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: p.FileName, URIBaseID: sarifSourceRoot},
+				Region: sarifRegion{
+					StartLine:   b.StartLine,
+					StartColumn: b.StartCol,
+					EndLine:     b.EndLine,
+					EndColumn:   b.EndCol,
+				},
+			}}},
+		})
+	}
+
+	return results
+}
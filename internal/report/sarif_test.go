@@ -0,0 +1,83 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+type sarifDoc struct {
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID string `json:"id"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+						EndLine     int `json:"endLine"`
+						EndColumn   int `json:"endColumn"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func TestSARIF(t *testing.T) {
+	c := testCoverage()
+	c.Profiles[0].Blocks = append(c.Profiles[0].Blocks,
+		cover.ProfileBlock{StartLine: 12, StartCol: 2, EndLine: 12, EndCol: 15, NumStmt: 1, Count: 1},
+		cover.ProfileBlock{StartLine: 12, StartCol: 16, EndLine: 12, EndCol: 30, NumStmt: 1, Count: 0},
+	)
+	c.ChangedLines = map[string][]int{
+		"foo.go":     {4, 8, 12},
+		"bar/bar.go": {4},
+		"baz.go":     {1},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf, report.FormatSARIF, c))
+
+	var doc sarifDoc
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "2.1.0", doc.Version)
+	require.Len(t, doc.Runs, 1)
+
+	run := doc.Runs[0]
+	require.Equal(t, "gocovsh", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	require.Len(t, run.Results, 2)
+
+	require.Equal(t, "uncovered", run.Results[0].RuleID)
+	location := run.Results[0].Locations[0].PhysicalLocation
+	require.Equal(t, "foo.go", location.ArtifactLocation.URI)
+	require.Equal(t, 7, location.Region.StartLine)
+	require.Equal(t, 20, location.Region.StartColumn)
+	require.Equal(t, 10, location.Region.EndLine)
+	require.Equal(t, 2, location.Region.EndColumn)
+
+	require.Equal(t, "partially-covered", run.Results[1].RuleID)
+	require.Equal(t, 12, run.Results[1].Locations[0].PhysicalLocation.Region.StartLine)
+	require.Equal(t, 16, run.Results[1].Locations[0].PhysicalLocation.Region.StartColumn)
+
+	t.Run("no diff", func(t *testing.T) {
+		require.Error(t, report.Write(bytes.NewBuffer(nil), report.FormatSARIF, testCoverage()))
+	})
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/orlangure/gocovsh/internal/model"
+)
+
+// Text renders a table of files with their statement counts and coverage
+// percentage, followed by the project total.
+func Text(w io.Writer, c *Coverage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "File\tStatements\tCovered\tCoverage"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	var total, covered int64
+
+	for _, p := range c.Profiles {
+		fileTotal, fileCovered := model.CountStatements(p)
+		total += fileTotal
+		covered += fileCovered
+
+		if _, err := fmt.Fprintf(
+			tw, "%s\t%d\t%d\t%.2f%%\n",
+			p.FileName, fileTotal, fileCovered, model.Percent(fileCovered, fileTotal),
+		); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p.FileName, err)
+		}
+	}
+
+	if _, err := fmt.Fprintf(
+		tw, "Total\t%d\t%d\t%.2f%%\n",
+		total, covered, model.Percent(covered, total),
+	); err != nil {
+		return fmt.Errorf("failed to write total: %w", err)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orlangure/gocovsh/internal/report"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func testCoverage() *report.Coverage {
+	return &report.Coverage{
+		ModuleName: "example.com/foo",
+		Profiles: []*cover.Profile{
+			{
+				FileName: "foo.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+					{StartLine: 7, StartCol: 20, EndLine: 10, EndCol: 2, NumStmt: 2, Count: 0},
+				},
+			},
+			{
+				FileName: "bar/bar.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+				},
+			},
+		},
+	}
+}
+
+func TestText(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf, report.FormatText, testCoverage()))
+
+	expected := `File        Statements  Covered  Coverage
+foo.go      3           1        33.33%
+bar/bar.go  1           1        100.00%
+Total       4           2        50.00%
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.Error(t, report.Write(buf, report.Format("foo"), testCoverage()))
+	require.Empty(t, buf.String())
+}
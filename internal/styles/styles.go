@@ -1,7 +1,7 @@
 package styles
 
 import (
-	"os"
+	"fmt"
 
 	catppuccin "github.com/catppuccin/go"
 	"github.com/charmbracelet/lipgloss"
@@ -14,6 +14,11 @@ type Theme struct {
 	SecondaryColor string
 	InactiveColor  string
 
+	// BackgroundColor and TextColor are only used outside of the terminal,
+	// for example in HTML reports.
+	BackgroundColor string
+	TextColor       string
+
 	NeutralLine   lipgloss.Style
 	CoveredLine   lipgloss.Style
 	UncoveredLine lipgloss.Style
@@ -30,6 +35,9 @@ func Default() Theme {
 		PrimaryColor:   "#00ff00",
 		SecondaryColor: "#ff0000",
 		InactiveColor:  "#7f7f7f",
+
+		BackgroundColor: "#1e1e1e",
+		TextColor:       "#d0d0d0",
 	}
 	t.setStyles()
 
@@ -41,18 +49,30 @@ func Catppuccin(cpn catppuccin.Theme) Theme {
 		PrimaryColor:   cpn.Green().Hex,
 		SecondaryColor: cpn.Red().Hex,
 		InactiveColor:  cpn.Subtext1().Hex,
+
+		BackgroundColor: cpn.Base().Hex,
+		TextColor:       cpn.Text().Hex,
 	}
 	t.setStyles()
 
 	return t
 }
 
-func SetTheme() {
-	theme := os.Getenv("GOCOVSH_THEME")
-
-	if variant := catppuccin.Variant(theme); variant != nil {
-		CurrentTheme = Catppuccin(variant)
-	} else {
+// UseTheme sets the theme by its name: "default" or one of the catppuccin
+// variants. An empty name selects the default theme.
+func UseTheme(name string) error {
+	switch name {
+	case "", "default":
 		CurrentTheme = Default()
+		return nil
 	}
+
+	variant := catppuccin.Variant(name)
+	if variant == nil {
+		return fmt.Errorf("unknown theme %q, use default, latte, frappe, macchiato or mocha", name)
+	}
+
+	CurrentTheme = Catppuccin(variant)
+
+	return nil
 }
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/orlangure/gocovsh/internal/program"
-	"github.com/orlangure/gocovsh/internal/styles"
 )
 
 var (
@@ -16,14 +17,24 @@ var (
 )
 
 func main() {
-	styles.SetTheme()
-
-	if err := program.New(
+	opts := []program.Option{
 		program.WithGoModInfo(),
 		program.WithBuildInfo(version, commit, date),
 		program.WithLogFile(os.Getenv("GOCOVSH_LOG_FILE")),
-	).Run(); err != nil {
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		opts = append(opts, program.WithUserConfigDir(filepath.Join(dir, "gocovsh")))
+	}
+
+	if err := program.New(opts...).Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
+
+		var exitErr *program.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
 		os.Exit(1)
 	}
 }